@@ -0,0 +1,477 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"snippetbox/internal/langdetect"
+	"snippetbox/internal/models"
+	"snippetbox/internal/validator"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// apiMaxBodySize is the largest request body accepted by the API, in bytes
+	apiMaxBodySize = 1 << 20
+	// apiMaxPageSize is the largest number of snippets listed by a single request
+	apiMaxPageSize = 100
+	// apiPasswordHeader carries the password of protected snippets
+	apiPasswordHeader = "X-Snippet-Password"
+)
+
+// problem is an RFC 7807 problem details object. Errors holds validation errors
+// of individual fields, GeneralErrors those of the request as a whole.
+type problem struct {
+	Type          string              `json:"type"`
+	Title         string              `json:"title"`
+	Status        int                 `json:"status"`
+	Detail        string              `json:"detail,omitempty"`
+	Errors        map[string][]string `json:"errors,omitempty"`
+	GeneralErrors []string            `json:"general_errors,omitempty"`
+}
+
+// apiSnippet is the JSON representation of a snippet. Expires is null for
+// snippets that never expire, ViewsLeft when views aren't limited. Content is
+// left out of listed protected and view limited snippets.
+type apiSnippet struct {
+	ID         int               `json:"id"`
+	Author     string            `json:"author"`
+	Title      string            `json:"title"`
+	Content    string            `json:"content,omitempty"`
+	Language   string            `json:"language"`
+	Tags       []string          `json:"tags,omitempty"`
+	Visibility models.Visibility `json:"visibility"`
+	Protected  bool              `json:"protected"`
+	ViewsLeft  *int              `json:"views_left"`
+	Created    time.Time         `json:"created"`
+	Expires    *time.Time        `json:"expires"`
+	ForkedFrom int               `json:"forked_from,omitempty"`
+	Stars      int               `json:"stars"`
+}
+
+func newAPISnippet(s *models.Snippet) *apiSnippet {
+	res := &apiSnippet{
+		ID:         s.ID,
+		Author:     s.Author,
+		Title:      s.Title,
+		Content:    s.Content,
+		Language:   s.Language,
+		Tags:       s.Tags,
+		Visibility: s.Visibility,
+		Protected:  s.Protected,
+		ViewsLeft:  s.ViewsLeft,
+		Created:    s.Created,
+		ForkedFrom: s.ForkedFromID,
+		Stars:      s.Stars,
+	}
+	if !s.Expires.IsZero() {
+		res.Expires = &s.Expires
+	}
+
+	return res
+}
+
+// apiSnippetList is the JSON representation of a page of snippets. Before and
+// After are the cursors of the adjacent pages, omitted when there are none.
+type apiSnippetList struct {
+	Snippets []*apiSnippet `json:"snippets"`
+	Before   int           `json:"before,omitempty"`
+	After    int           `json:"after,omitempty"`
+}
+
+// apiSnippetInput is the body of snippet create and update requests. Expires
+// is one of the configured expiry options, ExpiresAt overrides it with a date.
+// On update, a null Password keeps the current one and an empty one removes it.
+type apiSnippetInput struct {
+	Title      string            `json:"title"`
+	Content    string            `json:"content"`
+	Tags       []string          `json:"tags"`
+	Language   string            `json:"language"`
+	Visibility models.Visibility `json:"visibility"`
+	Password   *string           `json:"password"`
+	MaxViews   int               `json:"max_views"`
+	Expires    string            `json:"expires"`
+	ExpiresAt  *time.Time        `json:"expires_at"`
+}
+
+// form converts the input into a snippet form, so that it's validated the same
+// way, filling in defaults of omitted fields
+func (in *apiSnippetInput) form(expiry *expiryConfig) snippetCreateForm {
+	form := snippetCreateForm{
+		Title:      in.Title,
+		Content:    in.Content,
+		Tags:       strings.Join(in.Tags, ","),
+		Language:   in.Language,
+		Visibility: in.Visibility,
+		MaxViews:   in.MaxViews,
+		Expires:    in.Expires,
+	}
+
+	if form.Visibility == "" {
+		form.Visibility = models.VisibilityPublic
+	}
+	if form.Expires == "" {
+		form.Expires = expiry.Default()
+	}
+	if in.ExpiresAt != nil {
+		form.Expires = expiryCustom
+		form.ExpiresAt = in.ExpiresAt.UTC().Format(expiryDateLayout)
+	}
+	if in.Password != nil {
+		form.Password = *in.Password
+		form.RemovePassword = *in.Password == ""
+	}
+
+	return form
+}
+
+// writeJSON sends v encoded as JSON with given status code
+func (app *application) writeJSON(writer http.ResponseWriter, status int, v any, contentType string) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		app.apiServerError(writer, err)
+		return
+	}
+
+	writer.Header().Set("Content-Type", contentType)
+	writer.WriteHeader(status)
+	writer.Write(append(body, '\n'))
+}
+
+// readJSON decodes the JSON request body into dst, rejecting unknown fields
+// and bodies larger than apiMaxBodySize
+func (app *application) readJSON(writer http.ResponseWriter, req *http.Request, dst any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(writer, req.Body, apiMaxBodySize))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err != nil {
+		return err
+	}
+
+	if dec.Decode(&struct{}{}) != io.EOF {
+		return errors.New("body must contain a single JSON value")
+	}
+
+	return nil
+}
+
+// apiError sends a problem with given status code and detail
+func (app *application) apiError(writer http.ResponseWriter, status int, detail string) {
+	app.writeProblem(writer, &problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+}
+
+// apiUnauthorized sends a problem asking for a token
+func (app *application) apiUnauthorized(writer http.ResponseWriter, detail string) {
+	writer.Header().Set("WWW-Authenticate", `Bearer realm="snippetbox"`)
+	app.apiError(writer, http.StatusUnauthorized, detail)
+}
+
+// apiServerError is the API counterpart of serverError
+func (app *application) apiServerError(writer http.ResponseWriter, err error) {
+	trace := fmt.Sprintf("%s\n%s", err.Error(), debug.Stack())
+	app.errorLogger.Output(2, trace)
+
+	detail := ""
+	if app.debugMode {
+		detail = trace
+	}
+	app.apiError(writer, http.StatusInternalServerError, detail)
+}
+
+// apiValidationError sends validation errors of v as a problem
+func (app *application) apiValidationError(writer http.ResponseWriter, v validator.Validator) {
+	app.writeProblem(writer, &problem{
+		Type:          "about:blank",
+		Title:         http.StatusText(http.StatusUnprocessableEntity),
+		Status:        http.StatusUnprocessableEntity,
+		Detail:        "The request contains invalid fields",
+		Errors:        v.ValidationErrors,
+		GeneralErrors: v.GeneralErrors,
+	})
+}
+
+func (app *application) writeProblem(writer http.ResponseWriter, p *problem) {
+	app.writeJSON(writer, p.Status, p, "application/problem+json")
+}
+
+// apiToken returns the token authenticated by authenticateToken, nil for
+// anonymous requests
+func apiToken(req *http.Request) *models.Token {
+	token, _ := req.Context().Value(apiTokenContextKey).(*models.Token)
+	return token
+}
+
+// apiUserID returns the id of the owner of the request's token, 0 for anonymous
+// requests
+func apiUserID(req *http.Request) int {
+	if token := apiToken(req); token != nil {
+		return token.UserID
+	}
+	return 0
+}
+
+// apiSnippetID reads the id route parameter, sending a problem when it isn't
+// a valid snippet id
+func (app *application) apiSnippetID(writer http.ResponseWriter, req *http.Request) (int, bool) {
+	id, err := strconv.Atoi(httprouter.ParamsFromContext(req.Context()).ByName("id"))
+	if err != nil || id < 1 {
+		app.apiError(writer, http.StatusNotFound, "")
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (app *application) apiSnippetList(writer http.ResponseWriter, req *http.Request) {
+	before, okBefore := cursorParam(req, "before")
+	after, okAfter := cursorParam(req, "after")
+	if !okBefore || !okAfter || before > 0 && after > 0 {
+		app.apiError(writer, http.StatusBadRequest, "before and after must be snippet ids, and can't be combined")
+		return
+	}
+
+	limit := app.pageSize
+	if req.URL.Query().Has("limit") {
+		var err error
+		limit, err = strconv.Atoi(req.URL.Query().Get("limit"))
+		if err != nil || limit < 1 || limit > apiMaxPageSize {
+			app.apiError(writer, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", apiMaxPageSize))
+			return
+		}
+	}
+
+	cursor := models.Cursor{Before: before, After: after}
+
+	var page *models.SnippetPage
+	var err error
+	if tag := strings.ToLower(req.URL.Query().Get("tag")); tag != "" {
+		page, err = app.snippets.BrowseTag(tag, cursor, limit)
+	} else {
+		page, err = app.snippets.Browse(cursor, limit)
+	}
+	if err != nil {
+		app.apiServerError(writer, err)
+		return
+	}
+
+	res := &apiSnippetList{Snippets: []*apiSnippet{}, Before: page.Before, After: page.After}
+	for _, snippet := range page.Snippets {
+		item := newAPISnippet(snippet)
+		// apiSnippetView checks the password and counts the view first
+		if snippet.Protected || snippet.ViewsLeft != nil {
+			item.Content = ""
+		}
+		res.Snippets = append(res.Snippets, item)
+	}
+
+	app.writeJSON(writer, http.StatusOK, res, "application/json")
+}
+
+// apiSnippetView sends a snippet, counting it as viewed. Protected snippets of
+// other users require their password in the apiPasswordHeader header, wrong
+// passwords being rate limited like on the unlock page.
+func (app *application) apiSnippetView(writer http.ResponseWriter, req *http.Request) {
+	id, ok := app.apiSnippetID(writer, req)
+	if !ok {
+		return
+	}
+
+	viewerID := apiUserID(req)
+
+	snippet, err := app.snippets.Peek(id, viewerID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.apiError(writer, http.StatusNotFound, "")
+			return
+		}
+		app.apiServerError(writer, err)
+		return
+	}
+
+	if snippet.Protected && snippet.UserID != viewerID {
+		password := req.Header.Get(apiPasswordHeader)
+		if password == "" {
+			app.apiError(writer, http.StatusForbidden, "The snippet is password protected, pass the password in the "+apiPasswordHeader+" header")
+			return
+		}
+
+		if !app.unlockLimiter.Allow(id) {
+			app.apiError(writer, http.StatusTooManyRequests, "Too many wrong passwords, try again later")
+			return
+		}
+
+		err = app.snippets.Unlock(id, password)
+		if !errors.Is(err, models.ErrInvalidCredentials) {
+			app.unlockLimiter.Release(id)
+		}
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidCredentials) {
+				app.apiError(writer, http.StatusForbidden, "Wrong password")
+			} else if errors.Is(err, models.ErrNoRecord) {
+				app.apiError(writer, http.StatusNotFound, "")
+			} else {
+				app.apiServerError(writer, err)
+			}
+			return
+		}
+	}
+
+	snippet, err = app.snippets.Get(id, viewerID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.apiError(writer, http.StatusNotFound, "")
+			return
+		}
+		app.apiServerError(writer, err)
+		return
+	}
+
+	app.writeJSON(writer, http.StatusOK, newAPISnippet(snippet), "application/json")
+}
+
+// readSnippetInput reads and validates the body of create and update requests.
+// On failure the appropriate response is already written and ok is false.
+func (app *application) readSnippetInput(writer http.ResponseWriter, req *http.Request) (form snippetCreateForm, expires time.Time, ok bool) {
+	var in apiSnippetInput
+
+	err := app.readJSON(writer, req, &in)
+	if err != nil {
+		app.apiError(writer, http.StatusBadRequest, err.Error())
+		return form, expires, false
+	}
+
+	form = in.form(app.expiry)
+	form.validate()
+	expires = form.checkExpiry(app.expiry, true, time.Now())
+
+	if !form.Valid() {
+		app.apiValidationError(writer, form.Validator)
+		return form, expires, false
+	}
+
+	if form.Language == "" {
+		form.Language = langdetect.Detect(form.Title, form.Content)
+	}
+
+	return form, expires, true
+}
+
+func (app *application) apiSnippetCreate(writer http.ResponseWriter, req *http.Request) {
+	form, expires, ok := app.readSnippetInput(writer, req)
+	if !ok {
+		return
+	}
+
+	userID := apiUserID(req)
+
+	id, err := app.snippets.Insert(form.Title, form.Content, form.Language, form.tagList(), form.Visibility, form.Password, form.MaxViews, expires, userID, 0)
+	if err != nil {
+		app.apiServerError(writer, err)
+		return
+	}
+
+	snippet, err := app.snippets.Peek(id, userID)
+	if err != nil {
+		app.apiServerError(writer, err)
+		return
+	}
+
+	writer.Header().Set("Location", fmt.Sprintf("/api/v1/snippets/%d", id))
+	app.writeJSON(writer, http.StatusCreated, newAPISnippet(snippet), "application/json")
+}
+
+// apiOwnedSnippet is the API counterpart of ownedSnippet
+func (app *application) apiOwnedSnippet(writer http.ResponseWriter, req *http.Request) *models.Snippet {
+	id, ok := app.apiSnippetID(writer, req)
+	if !ok {
+		return nil
+	}
+
+	userID := apiUserID(req)
+
+	snippet, err := app.snippets.Lookup(id, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.apiError(writer, http.StatusNotFound, "")
+			return nil
+		}
+		app.apiServerError(writer, err)
+		return nil
+	}
+
+	if snippet.UserID != userID {
+		app.apiError(writer, http.StatusForbidden, "Only the author can change the snippet")
+		return nil
+	}
+
+	return snippet
+}
+
+func (app *application) apiSnippetUpdate(writer http.ResponseWriter, req *http.Request) {
+	snippet := app.apiOwnedSnippet(writer, req)
+	if snippet == nil {
+		return
+	}
+
+	form, expires, ok := app.readSnippetInput(writer, req)
+	if !ok {
+		return
+	}
+
+	var password *string
+	switch {
+	case form.RemovePassword:
+		password = new(string)
+	case form.Password != "":
+		password = &form.Password
+	}
+
+	err := app.snippets.Update(snippet.ID, form.Title, form.Content, form.Language, form.tagList(), form.Visibility, password, form.MaxViews, expires)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.apiError(writer, http.StatusNotFound, "")
+			return
+		}
+		app.apiServerError(writer, err)
+		return
+	}
+
+	snippet, err = app.snippets.Peek(snippet.ID, snippet.UserID)
+	if err != nil {
+		app.apiServerError(writer, err)
+		return
+	}
+
+	app.writeJSON(writer, http.StatusOK, newAPISnippet(snippet), "application/json")
+}
+
+func (app *application) apiSnippetDelete(writer http.ResponseWriter, req *http.Request) {
+	snippet := app.apiOwnedSnippet(writer, req)
+	if snippet == nil {
+		return
+	}
+
+	err := app.snippets.Delete(snippet.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.apiError(writer, http.StatusNotFound, "")
+			return
+		}
+		app.apiServerError(writer, err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"snippetbox/internal/assert"
+	"snippetbox/internal/models/mocks"
+	"strings"
+	"testing"
+)
+
+func TestAPISnippetList(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "First page",
+			urlPath:  "/api/v1/snippets",
+			wantCode: http.StatusOK,
+			wantBody: `"title":"An old silent pond..."`,
+		},
+		{
+			name:     "Cursor",
+			urlPath:  "/api/v1/snippets?before=2",
+			wantCode: http.StatusOK,
+			wantBody: `"after":1`,
+		},
+		{
+			name:     "Tag",
+			urlPath:  "/api/v1/snippets?tag=Haiku",
+			wantCode: http.StatusOK,
+			wantBody: `"tags":["haiku"]`,
+		},
+		{
+			name:     "Empty page",
+			urlPath:  "/api/v1/snippets?after=1",
+			wantCode: http.StatusOK,
+			wantBody: `{"snippets":[]}`,
+		},
+		{
+			name:     "Invalid cursor",
+			urlPath:  "/api/v1/snippets?before=foo",
+			wantCode: http.StatusBadRequest,
+			wantBody: `"status":400`,
+		},
+		{
+			name:     "Invalid limit",
+			urlPath:  "/api/v1/snippets?limit=1000",
+			wantCode: http.StatusBadRequest,
+			wantBody: `"detail":"limit must be between 1 and 100"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.apiRequest(t, http.MethodGet, tt.urlPath, "", "")
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestAPISnippetListContent(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	code, _, body := ts.apiRequest(t, http.MethodGet, "/api/v1/snippets", "", "")
+	assert.Equal(t, code, http.StatusOK)
+
+	var res apiSnippetList
+	err := json.Unmarshal([]byte(body), &res)
+	assert.NilError(t, err)
+
+	contents := map[int]string{}
+	for _, snippet := range res.Snippets {
+		contents[snippet.ID] = snippet.Content
+	}
+
+	tests := []struct {
+		name        string
+		id          int
+		wantContent string
+	}{
+		{
+			name:        "Public",
+			id:          1,
+			wantContent: "An old silent pond...",
+		},
+		{
+			name:        "Protected",
+			id:          6,
+			wantContent: "",
+		},
+		{
+			name:        "Limited views",
+			id:          7,
+			wantContent: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, ok := contents[tt.id]
+
+			assert.Equal(t, ok, true)
+			assert.Equal(t, content, tt.wantContent)
+		})
+	}
+}
+
+func TestAPISnippetView(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		password string
+		token    string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Valid ID",
+			urlPath:  "/api/v1/snippets/1",
+			wantCode: http.StatusOK,
+			wantBody: `"id":1,"author":"test","title":"An old silent pond..."`,
+		},
+		{
+			name:     "Never expires",
+			urlPath:  "/api/v1/snippets/1",
+			wantCode: http.StatusOK,
+			wantBody: `"views_left":null`,
+		},
+		{
+			name:     "Private",
+			urlPath:  "/api/v1/snippets/5",
+			wantCode: http.StatusNotFound,
+			wantBody: `"title":"Not Found"`,
+		},
+		{
+			name:     "Private by owner",
+			urlPath:  "/api/v1/snippets/5",
+			token:    mocks.MockReadToken,
+			wantCode: http.StatusOK,
+			wantBody: `"visibility":"private"`,
+		},
+		{
+			name:     "Protected",
+			urlPath:  "/api/v1/snippets/6",
+			wantCode: http.StatusForbidden,
+			wantBody: "X-Snippet-Password",
+		},
+		{
+			name:     "Wrong password",
+			urlPath:  "/api/v1/snippets/6",
+			password: "sesame",
+			wantCode: http.StatusForbidden,
+			wantBody: `"detail":"Wrong password"`,
+		},
+		{
+			name:     "Password",
+			urlPath:  "/api/v1/snippets/6",
+			password: "open sesame",
+			wantCode: http.StatusOK,
+			wantBody: "user=admin password=hunter2",
+		},
+		{
+			name:     "Invalid ID",
+			urlPath:  "/api/v1/snippets/foo",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, ts.URL+tt.urlPath, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.password != "" {
+				req.Header.Set(apiPasswordHeader, tt.password)
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			rs, err := ts.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rs.Body.Close()
+
+			assert.Equal(t, rs.StatusCode, tt.wantCode)
+			if tt.wantCode != http.StatusOK {
+				assert.Equal(t, rs.Header.Get("Content-Type"), "application/problem+json")
+			}
+			if tt.wantBody != "" {
+				body := new(strings.Builder)
+				io.Copy(body, rs.Body)
+				assert.StringContains(t, body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestAPIAuthentication(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	code, header, body := ts.apiRequest(t, http.MethodPost, "/api/v1/snippets", `{"title":"O snail"}`, "")
+	assert.Equal(t, code, http.StatusUnauthorized)
+	assert.StringContains(t, header.Get("WWW-Authenticate"), "Bearer")
+	assert.StringContains(t, body, `"detail":"Authentication required"`)
+
+	code, _, _ = ts.apiRequest(t, http.MethodGet, "/api/v1/snippets", "", "sbx_revoked")
+	assert.Equal(t, code, http.StatusUnauthorized)
+
+	code, _, body = ts.apiRequest(t, http.MethodPost, "/api/v1/snippets", `{"title":"O snail"}`, mocks.MockReadToken)
+	assert.Equal(t, code, http.StatusForbidden)
+	assert.StringContains(t, body, "lacks the write scope")
+
+	// sessions don't authenticate API requests
+	ts.login(t)
+	code, _, _ = ts.apiRequest(t, http.MethodDelete, "/api/v1/snippets/1", "", "")
+	assert.Equal(t, code, http.StatusUnauthorized)
+}
+
+func TestAPISnippetCreate(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name         string
+		body         string
+		wantCode     int
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:         "Valid submission",
+			body:         `{"title":"Build log","content":"ok","tags":["ci"]}`,
+			wantCode:     http.StatusCreated,
+			wantLocation: "/api/v1/snippets/1",
+		},
+		{
+			name:         "All fields",
+			body:         `{"title":"Build log","content":"ok","language":"go","visibility":"unlisted","password":"open sesame","max_views":1,"expires":"1d"}`,
+			wantCode:     http.StatusCreated,
+			wantLocation: "/api/v1/snippets/1",
+		},
+		{
+			name:         "Expiration date",
+			body:         `{"title":"Build log","content":"ok","expires_at":"2999-01-01T00:00:00Z"}`,
+			wantCode:     http.StatusCreated,
+			wantLocation: "/api/v1/snippets/1",
+		},
+		{
+			name:     "Invalid fields",
+			body:     `{"title":"","content":"ok","visibility":"secret"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"errors":{"title":["This field cannot be blank"],"visibility":["This field must be one of public, unlisted or private"]}`,
+		},
+		{
+			name:     "Invalid expiry",
+			body:     `{"title":"Build log","content":"ok","expires":"7"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"expires":["This field must be one of the offered options"]`,
+		},
+		{
+			name:     "Unknown field",
+			body:     `{"title":"Build log","content":"ok","author":"someone"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `unknown field`,
+		},
+		{
+			name:     "Malformed JSON",
+			body:     `{"title":`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Trailing data",
+			body:     `{"title":"Build log","content":"ok"} {}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: "single JSON value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, header, body := ts.apiRequest(t, http.MethodPost, "/api/v1/snippets", tt.body, mocks.MockWriteToken)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestAPISnippetUpdate(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "Valid submission",
+			urlPath:  "/api/v1/snippets/1",
+			body:     `{"title":"An old silent pond","content":"A frog jumps into the pond","password":""}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Invalid fields",
+			urlPath:  "/api/v1/snippets/1",
+			body:     `{"title":"An old silent pond","content":""}`,
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Foreign snippet",
+			urlPath:  "/api/v1/snippets/3",
+			body:     `{"title":"Mine now","content":"Mine now"}`,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/api/v1/snippets/2",
+			body:     `{"title":"Missing","content":"Missing"}`,
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := ts.apiRequest(t, http.MethodPut, tt.urlPath, tt.body, mocks.MockWriteToken)
+
+			assert.Equal(t, code, tt.wantCode)
+		})
+	}
+}
+
+func TestAPISnippetDelete(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		token    string
+		wantCode int
+	}{
+		{
+			name:     "Own snippet",
+			urlPath:  "/api/v1/snippets/1",
+			token:    mocks.MockWriteToken,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Foreign snippet",
+			urlPath:  "/api/v1/snippets/3",
+			token:    mocks.MockWriteToken,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Read token",
+			urlPath:  "/api/v1/snippets/1",
+			token:    mocks.MockReadToken,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Anonymous",
+			urlPath:  "/api/v1/snippets/1",
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := ts.apiRequest(t, http.MethodDelete, tt.urlPath, "", tt.token)
+
+			assert.Equal(t, code, tt.wantCode)
+		})
+	}
+}
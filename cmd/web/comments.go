@@ -0,0 +1,217 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"snippetbox/internal/highlight"
+	"snippetbox/internal/models"
+	"snippetbox/internal/validator"
+	"strconv"
+)
+
+// maxCommentLength is the maximum number of characters of a comment
+const maxCommentLength = 2000
+
+type commentForm struct {
+	Content string `form:"content"`
+	// ParentID is the id of the comment replied to, 0 for top level comments
+	ParentID int `form:"parent_id"`
+	// Version is the version of the snippet lines LineStart to LineEnd were
+	// picked from. Comments without lines are about the whole snippet.
+	Version             int `form:"version"`
+	LineStart           int `form:"line_start"`
+	LineEnd             int `form:"line_end"`
+	validator.Validator `form:"-"`
+}
+
+func (form *commentForm) validate() {
+	form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Content, maxCommentLength), "content", fmt.Sprintf("This field cannot exceed %d characters", maxCommentLength))
+}
+
+// checkLines validates the line fields against contents of the snippet
+// versions, as returned by versionContents, and returns the lines the comment
+// is about. The range is zero for comments about the whole snippet and for
+// replies, which follow the comment they reply to.
+func (form *commentForm) checkLines(contents []string) models.LineRange {
+	if form.ParentID != 0 || (form.LineStart == 0 && form.LineEnd == 0) {
+		return models.LineRange{}
+	}
+	if form.LineEnd == 0 {
+		form.LineEnd = form.LineStart
+	}
+
+	if form.Version < 1 || form.Version > len(contents) {
+		form.AddValidationError("lines", "These lines must belong to an existing version of the snippet")
+		return models.LineRange{}
+	}
+
+	count := highlight.CountLines(contents[form.Version-1])
+	form.CheckField(form.LineStart >= 1 && form.LineStart <= form.LineEnd && form.LineEnd <= count,
+		"lines", fmt.Sprintf("This field must be a range of lines between 1 and %d", count))
+
+	return models.LineRange{Version: form.Version, Start: form.LineStart, End: form.LineEnd}
+}
+
+// snippetCommentPost adds a comment, or a reply to one, to the snippet
+// identified by the id route parameter. Snippets with limited views can only be
+// commented on by their owner, as the page re-rendered on errors would show
+// their content without counting a view.
+func (app *application) snippetCommentPost(writer http.ResponseWriter, req *http.Request) {
+	params := httprouter.ParamsFromContext(req.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(writer)
+		return
+	}
+
+	userID := app.sessionManager.GetInt(req.Context(), "authenticatedUserID")
+
+	snippet := app.peekSnippet(writer, req, id, userID)
+	if snippet == nil {
+		return
+	}
+
+	if !peekable(snippet, userID) {
+		app.clientError(writer, http.StatusForbidden)
+		return
+	}
+
+	var form commentForm
+
+	err = app.decodePostForm(req, &form)
+	if err != nil {
+		app.clientError(writer, http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := app.snippets.Revisions(snippet.ID, userID)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	form.validate()
+	lines := form.checkLines(versionContents(revisions, snippet))
+
+	if !form.Valid() {
+		app.renderSnippetView(writer, req, snippet, userID, http.StatusUnprocessableEntity, form)
+		return
+	}
+
+	commentID, err := app.comments.Insert(snippet.ID, userID, form.ParentID, lines, form.Content)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(writer)
+			return
+		}
+		app.serverError(writer, err)
+		return
+	}
+
+	app.sessionManager.Put(req.Context(), "flash", "Comment successfully added!")
+
+	http.Redirect(writer, req, fmt.Sprintf("/snippet/view/%d#comment-%d", snippet.ID, commentID), http.StatusSeeOther)
+}
+
+// ownedComment fetches comment identified by the id route parameter and makes
+// sure it was written by the authenticated user. On failure the appropriate
+// response is already written and nil is returned.
+func (app *application) ownedComment(writer http.ResponseWriter, req *http.Request) *models.Comment {
+	params := httprouter.ParamsFromContext(req.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(writer)
+		return nil
+	}
+
+	comment, err := app.comments.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(writer)
+			return nil
+		}
+		app.serverError(writer, err)
+		return nil
+	}
+
+	if comment.UserID != app.sessionManager.GetInt(req.Context(), "authenticatedUserID") {
+		app.clientError(writer, http.StatusForbidden)
+		return nil
+	}
+
+	return comment
+}
+
+func (app *application) commentEdit(writer http.ResponseWriter, req *http.Request) {
+	comment := app.ownedComment(writer, req)
+	if comment == nil {
+		return
+	}
+
+	data := app.newTemplateData(req)
+	data.Comment = comment
+	data.Form = commentForm{Content: comment.Content}
+
+	app.render(writer, http.StatusOK, "comment.tmpl.html", data)
+}
+
+func (app *application) commentEditPost(writer http.ResponseWriter, req *http.Request) {
+	comment := app.ownedComment(writer, req)
+	if comment == nil {
+		return
+	}
+
+	var form commentForm
+
+	err := app.decodePostForm(req, &form)
+	if err != nil {
+		app.clientError(writer, http.StatusBadRequest)
+		return
+	}
+
+	form.validate()
+
+	if !form.Valid() {
+		data := app.newTemplateData(req)
+		data.Comment = comment
+		data.Form = form
+		app.render(writer, http.StatusUnprocessableEntity, "comment.tmpl.html", data)
+		return
+	}
+
+	err = app.comments.Update(comment.ID, form.Content)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	app.sessionManager.Put(req.Context(), "flash", "Comment successfully updated!")
+
+	http.Redirect(writer, req, fmt.Sprintf("/snippet/view/%d#comment-%d", comment.SnippetID, comment.ID), http.StatusSeeOther)
+}
+
+func (app *application) commentDeletePost(writer http.ResponseWriter, req *http.Request) {
+	comment := app.ownedComment(writer, req)
+	if comment == nil {
+		return
+	}
+
+	err := app.comments.Delete(comment.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(writer)
+			return
+		}
+		app.serverError(writer, err)
+		return
+	}
+
+	app.sessionManager.Put(req.Context(), "flash", "Comment successfully deleted!")
+
+	http.Redirect(writer, req, fmt.Sprintf("/snippet/view/%d#comments", comment.SnippetID), http.StatusSeeOther)
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"snippetbox/internal/assert"
+	"strings"
+	"testing"
+)
+
+func TestSnippetComments(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Anonymous", func(t *testing.T) {
+		code, _, body := ts.get(t, "/snippet/view/1")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "<h3 id='comments'>Comments (4)</h3>")
+		assert.StringContains(t, body, "<div class='comment indent-0' id='comment-1'>")
+		assert.StringContains(t, body, "<div class='comment indent-1' id='comment-2'>")
+		assert.StringContains(t, body, "<p><a href='/user/login'>Log in</a> to comment.</p>")
+	})
+
+	t.Run("No comments", func(t *testing.T) {
+		code, _, body := ts.get(t, "/snippet/view/3")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "<p>No comments yet.</p>")
+	})
+
+	ts.login(t)
+
+	t.Run("Authenticated", func(t *testing.T) {
+		code, _, body := ts.get(t, "/snippet/view/1")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "<form action='/snippet/comment/1' method='POST'>")
+		assert.StringContains(t, body, "<a href='/comment/edit/1'>Edit</a>")
+		if strings.Contains(body, "<a href='/comment/edit/2'>Edit</a>") {
+			t.Error("got edit link of a comment by someone else")
+		}
+	})
+}
+
+func TestLineComments(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name        string
+		urlPath     string
+		wantBody    []string
+		notWantBody []string
+	}{
+		{
+			name:    "Current version",
+			urlPath: "/snippet/view/1",
+			wantBody: []string{
+				"<span class='line-comment indent-0'><a href='#comment-4'><strong>test</strong></a> on <a href='#L1'>L1</a>: Maybe drop the ellipsis</span>",
+				"<em>on <a href='#L1'>L1</a></em>",
+				"<em>on <a href='/snippet/view/1/rev/1#L1'>L1 of revision 1</a></em>",
+			},
+			notWantBody: []string{"<a href='#comment-5'><strong>"},
+		},
+		{
+			name:    "Revision",
+			urlPath: "/snippet/view/1/rev/1",
+			wantBody: []string{
+				"<span class='line-comment indent-0'><a href='#comment-5'><strong>someone else</strong></a> on <a href='#L1'>L1</a>: Too short</span>",
+			},
+			notWantBody: []string{"<a href='#comment-4'><strong>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, http.StatusOK)
+			for _, want := range tt.wantBody {
+				assert.StringContains(t, body, want)
+			}
+			for _, notWant := range tt.notWantBody {
+				if strings.Contains(body, notWant) {
+					t.Errorf("got: %q; expected not to contain: %q", body, notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestSnippetCommentPost(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, _, body := ts.get(t, "/user/login")
+
+		form := url.Values{}
+		form.Add("content", "Lovely haiku")
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, header, _ := ts.postForm(t, "/snippet/comment/1", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/user/login")
+	})
+
+	ts.login(t)
+
+	_, _, body := ts.get(t, "/snippet/view/1")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		content      string
+		parentID     string
+		lines        []string
+		wantCode     int
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:         "Valid submission",
+			urlPath:      "/snippet/comment/1",
+			content:      "Lovely haiku",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1#comment-3",
+		},
+		{
+			name:         "Reply",
+			urlPath:      "/snippet/comment/1",
+			content:      "Indeed",
+			parentID:     "2",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1#comment-3",
+		},
+		{
+			name:     "Empty content",
+			urlPath:  "/snippet/comment/1",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field cannot be blank",
+		},
+		{
+			name:     "Too long",
+			urlPath:  "/snippet/comment/1",
+			content:  strings.Repeat("a", maxCommentLength+1),
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field cannot exceed 2000 characters",
+		},
+		{
+			name:     "Empty reply",
+			urlPath:  "/snippet/comment/1",
+			parentID: "2",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "<details open>",
+		},
+		{
+			name:         "Line comment",
+			urlPath:      "/snippet/comment/1",
+			content:      "Maybe drop the ellipsis",
+			lines:        []string{"2", "1", "1"},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1#comment-3",
+		},
+		{
+			name:         "Single line",
+			urlPath:      "/snippet/comment/1",
+			content:      "Maybe drop the ellipsis",
+			lines:        []string{"2", "1", ""},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1#comment-3",
+		},
+		{
+			name:         "Outdated version",
+			urlPath:      "/snippet/comment/1",
+			content:      "Too short",
+			lines:        []string{"1", "1", "1"},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1#comment-3",
+		},
+		{
+			name:         "Reply with lines",
+			urlPath:      "/snippet/comment/1",
+			content:      "Indeed",
+			parentID:     "4",
+			lines:        []string{"9", "9", "9"},
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1#comment-3",
+		},
+		{
+			name:     "Lines out of range",
+			urlPath:  "/snippet/comment/1",
+			content:  "Maybe drop the ellipsis",
+			lines:    []string{"2", "1", "2"},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field must be a range of lines between 1 and 1",
+		},
+		{
+			name:     "Reversed lines",
+			urlPath:  "/snippet/comment/1",
+			content:  "Maybe drop the ellipsis",
+			lines:    []string{"2", "2", "1"},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field must be a range of lines between 1 and 1",
+		},
+		{
+			name:     "Unknown version",
+			urlPath:  "/snippet/comment/1",
+			content:  "Maybe drop the ellipsis",
+			lines:    []string{"3", "1", "1"},
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "These lines must belong to an existing version of the snippet",
+		},
+		{
+			name:     "Unknown parent",
+			urlPath:  "/snippet/comment/1",
+			content:  "Indeed",
+			parentID: "9",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:         "Protected",
+			urlPath:      "/snippet/comment/6",
+			content:      "Let me in",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/unlock/6",
+		},
+		{
+			name:     "Non-existent snippet",
+			urlPath:  "/snippet/comment/2",
+			content:  "Lovely haiku",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Limited views",
+			urlPath:  "/snippet/comment/7",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("content", tt.content)
+			if tt.parentID != "" {
+				form.Add("parent_id", tt.parentID)
+			}
+			if tt.lines != nil {
+				form.Add("version", tt.lines[0])
+				form.Add("line_start", tt.lines[1])
+				form.Add("line_end", tt.lines[2])
+			}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, header, body := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("Limited views content", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("csrf_token", validCSRFToken)
+
+		_, _, body := ts.postForm(t, "/snippet/comment/7", form)
+
+		if strings.Contains(body, "The vault code is 1234") {
+			t.Error("got content of a snippet with limited views without counting a view")
+		}
+	})
+}
+
+func TestCommentEdit(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.login(t)
+
+	getTests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Author",
+			urlPath:  "/comment/edit/1",
+			wantCode: http.StatusOK,
+			wantBody: "<textarea name='content'>Lovely haiku</textarea>",
+		},
+		{
+			name:     "Not author",
+			urlPath:  "/comment/edit/2",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/comment/edit/9",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range getTests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+
+	_, _, body := ts.get(t, "/comment/edit/1")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	postTests := []struct {
+		name         string
+		urlPath      string
+		content      string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Valid submission",
+			urlPath:      "/comment/edit/1",
+			content:      "Lovely haiku, really",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1#comment-1",
+		},
+		{
+			name:     "Empty content",
+			urlPath:  "/comment/edit/1",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Not author",
+			urlPath:  "/comment/edit/2",
+			content:  "Lovely haiku, really",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range postTests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("content", tt.content)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, header, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+		})
+	}
+}
+
+func TestCommentDelete(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.login(t)
+
+	_, _, body := ts.get(t, "/snippet/view/1")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Author",
+			urlPath:      "/comment/delete/1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1#comments",
+		},
+		{
+			name:     "Not author",
+			urlPath:  "/comment/delete/2",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/comment/delete/9",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, header, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+		})
+	}
+}
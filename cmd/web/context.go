@@ -3,3 +3,6 @@ package main
 type contextKey string
 
 var isAuthenticatedContextKey = contextKey("isAuthenticated")
+
+// apiTokenContextKey holds the token authenticated by authenticateToken
+var apiTokenContextKey = contextKey("apiToken")
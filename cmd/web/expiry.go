@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// expiryNever is the expiry option of snippets that are kept until deleted
+	expiryNever = "never"
+	// expiryCustom is the expires form value of snippets expiring at a date
+	// picked by the user
+	expiryCustom = "custom"
+	// expiryDateLayout is the format of datetime-local inputs, in UTC
+	expiryDateLayout = "2006-01-02T15:04"
+)
+
+// Default expiry configuration, overridden with -expiry-options and -max-expiry
+const (
+	defaultExpiryOptions = "1y,1M,1w,1d,1h,10m,never"
+	defaultMaxExpiry     = expiryNever
+)
+
+// expiryUnits maps suffixes of expiry options to the names of their units
+var expiryUnits = map[byte]string{
+	'm': "minute",
+	'h': "hour",
+	'd': "day",
+	'w': "week",
+	'M': "month",
+	'y': "year",
+}
+
+// expiryOption is a period after which snippets expire, written as a number
+// followed by a unit suffix, e.g. 10m, 1w or 1M for a month, or never
+type expiryOption struct {
+	Value string
+	Label string
+	n     int
+	unit  byte
+}
+
+func parseExpiryOption(value string) (expiryOption, error) {
+	if value == expiryNever {
+		return expiryOption{Value: value, Label: "Never"}, nil
+	}
+
+	if len(value) < 2 {
+		return expiryOption{}, fmt.Errorf("invalid expiry option %q", value)
+	}
+
+	unit := value[len(value)-1]
+	name, ok := expiryUnits[unit]
+	if !ok {
+		return expiryOption{}, fmt.Errorf("invalid expiry option %q: unknown unit %q", value, unit)
+	}
+
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n < 1 {
+		return expiryOption{}, fmt.Errorf("invalid expiry option %q: expected a positive number of %ss", value, name)
+	}
+
+	label := fmt.Sprintf("%d %s", n, name)
+	if n > 1 {
+		label += "s"
+	}
+
+	return expiryOption{Value: value, Label: label, n: n, unit: unit}, nil
+}
+
+// never reports whether snippets with the option don't expire
+func (o expiryOption) never() bool {
+	return o.unit == 0
+}
+
+// from returns the expiration date of snippets created at t, zero when they
+// never expire
+func (o expiryOption) from(t time.Time) time.Time {
+	switch o.unit {
+	case 'm':
+		return t.Add(time.Duration(o.n) * time.Minute)
+	case 'h':
+		return t.Add(time.Duration(o.n) * time.Hour)
+	case 'd':
+		return t.AddDate(0, 0, o.n)
+	case 'w':
+		return t.AddDate(0, 0, 7*o.n)
+	case 'M':
+		return t.AddDate(0, o.n, 0)
+	case 'y':
+		return t.AddDate(o.n, 0, 0)
+	default:
+		return time.Time{}
+	}
+}
+
+// expiryConfig holds the expiry options offered when creating snippets, the
+// first one being the default, and the furthest expiration allowed
+type expiryConfig struct {
+	Options []expiryOption
+	Max     expiryOption
+}
+
+// parseExpiryConfig parses a comma separated list of expiry options and the
+// maximum expiry. None of the options can exceed the maximum, and never is
+// only allowed when the maximum is never too.
+func parseExpiryConfig(options, max string) (*expiryConfig, error) {
+	config := &expiryConfig{}
+
+	var err error
+	config.Max, err = parseExpiryOption(max)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	for _, value := range strings.Split(options, ",") {
+		option, err := parseExpiryOption(strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		if !config.allows(option.from(now), now) {
+			return nil, fmt.Errorf("expiry option %s exceeds maximum expiry %s", option.Value, config.Max.Value)
+		}
+		config.Options = append(config.Options, option)
+	}
+
+	return config, nil
+}
+
+// allows reports whether snippets created at now may expire at expires, zero
+// meaning never
+func (c *expiryConfig) allows(expires, now time.Time) bool {
+	if c.Max.never() {
+		return true
+	}
+	return !expires.IsZero() && !expires.After(c.Max.from(now))
+}
+
+// Default returns the value of the default expiry option
+func (c *expiryConfig) Default() string {
+	return c.Options[0].Value
+}
+
+// resolve returns the expiration date of a snippet created at now with the
+// expires and expiresAt form values, zero when it never expires. The error
+// message is meant for users.
+func (c *expiryConfig) resolve(expires, expiresAt string, now time.Time) (time.Time, error) {
+	if expires == expiryCustom {
+		date, err := time.Parse(expiryDateLayout, expiresAt)
+		if err != nil {
+			return time.Time{}, errors.New("This field must be a valid date and time")
+		}
+		if !date.After(now) {
+			return time.Time{}, errors.New("This field must be a date in the future")
+		}
+		if !c.allows(date, now) {
+			return time.Time{}, fmt.Errorf("This field cannot be more than %s away", c.Max.Label)
+		}
+		return date, nil
+	}
+
+	for _, option := range c.Options {
+		if option.Value == expires {
+			return option.from(now), nil
+		}
+	}
+
+	return time.Time{}, errors.New("This field must be one of the offered options")
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"snippetbox/internal/assert"
+	"testing"
+	"time"
+)
+
+func TestParseExpiryConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   string
+		max       string
+		wantValid bool
+	}{
+		{
+			name:      "Default",
+			options:   defaultExpiryOptions,
+			max:       defaultMaxExpiry,
+			wantValid: true,
+		},
+		{
+			name:      "Within maximum",
+			options:   "10m, 1d,2w",
+			max:       "1M",
+			wantValid: true,
+		},
+		{
+			name:    "Exceeding maximum",
+			options: "1d,1y",
+			max:     "1M",
+		},
+		{
+			name:    "Never with maximum",
+			options: "1d,never",
+			max:     "1y",
+		},
+		{
+			name:    "Unknown unit",
+			options: "1d,1s",
+			max:     "never",
+		},
+		{
+			name:    "Zero",
+			options: "0d",
+			max:     "never",
+		},
+		{
+			name:    "Empty option",
+			options: "1d,",
+			max:     "never",
+		},
+		{
+			name:    "Invalid maximum",
+			options: "1d",
+			max:     "forever",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseExpiryConfig(tt.options, tt.max)
+			assert.Equal(t, err == nil, tt.wantValid)
+		})
+	}
+}
+
+func TestExpiryConfigResolve(t *testing.T) {
+	expiry, err := parseExpiryConfig("1M,10m,never", "never")
+	assert.NilError(t, err)
+
+	capped, err := parseExpiryConfig("1w", "1M")
+	assert.NilError(t, err)
+
+	now := time.Date(2023, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		expiry    *expiryConfig
+		expires   string
+		expiresAt string
+		want      time.Time
+		wantErr   bool
+	}{
+		{
+			name:    "Minutes",
+			expiry:  expiry,
+			expires: "10m",
+			want:    time.Date(2023, 1, 31, 12, 10, 0, 0, time.UTC),
+		},
+		{
+			name:    "Month",
+			expiry:  expiry,
+			expires: "1M",
+			want:    now.AddDate(0, 1, 0),
+		},
+		{
+			name:    "Never",
+			expiry:  expiry,
+			expires: "never",
+		},
+		{
+			name:    "Not offered",
+			expiry:  capped,
+			expires: "never",
+			wantErr: true,
+		},
+		{
+			name:      "Custom",
+			expiry:    capped,
+			expires:   "custom",
+			expiresAt: "2023-02-14T09:30",
+			want:      time.Date(2023, 2, 14, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:      "Custom past",
+			expiry:    expiry,
+			expires:   "custom",
+			expiresAt: "2023-01-31T11:59",
+			wantErr:   true,
+		},
+		{
+			name:      "Custom beyond maximum",
+			expiry:    capped,
+			expires:   "custom",
+			expiresAt: "2023-04-01T00:00",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.expiry.resolve(tt.expires, tt.expiresAt, now)
+
+			assert.Equal(t, err != nil, tt.wantErr)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
@@ -4,68 +4,930 @@ import (
 	"errors"
 	"fmt"
 	"github.com/julienschmidt/httprouter"
+	"io"
+	"mime"
 	"net/http"
+	"regexp"
+	"snippetbox/internal/diff"
+	"snippetbox/internal/highlight"
+	"snippetbox/internal/langdetect"
 	"snippetbox/internal/models"
+	"snippetbox/internal/query"
 	"snippetbox/internal/validator"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func (app *application) home(writer http.ResponseWriter, req *http.Request) {
-	latestSnippets, err := app.snippets.Latest()
+	before, okBefore := cursorParam(req, "before")
+	after, okAfter := cursorParam(req, "after")
+	if !okBefore || !okAfter || before > 0 && after > 0 {
+		app.clientError(writer, http.StatusBadRequest)
+		return
+	}
+
+	page, err := app.snippets.Browse(models.Cursor{Before: before, After: after}, app.pageSize)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	tags, err := app.snippets.TagCloud(tagCloudSize)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	data := app.newTemplateData(req)
+	data.Snippets = page.Snippets
+	data.SnippetPage = page
+	data.TagCloud = newTagCloud(tags)
+
+	app.render(writer, http.StatusOK, "home.tmpl.html", data)
+}
+
+func (app *application) tagView(writer http.ResponseWriter, req *http.Request) {
+	params := httprouter.ParamsFromContext(req.Context())
+
+	tag := strings.ToLower(params.ByName("name"))
+	if !validTag(tag) {
+		app.notFound(writer)
+		return
+	}
+
+	before, okBefore := cursorParam(req, "before")
+	after, okAfter := cursorParam(req, "after")
+	if !okBefore || !okAfter || before > 0 && after > 0 {
+		app.clientError(writer, http.StatusBadRequest)
+		return
+	}
+
+	page, err := app.snippets.BrowseTag(tag, models.Cursor{Before: before, After: after}, app.pageSize)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	data := app.newTemplateData(req)
+	data.Tag = tag
+	data.Snippets = page.Snippets
+	data.SnippetPage = page
+
+	app.render(writer, http.StatusOK, "tag.tmpl.html", data)
+}
+
+func (app *application) snippetView(writer http.ResponseWriter, req *http.Request) {
+	params := httprouter.ParamsFromContext(req.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(writer)
+		return
+	}
+
+	mediaType := negotiate(writer, req, "text/html", "application/json", "text/plain")
+
+	viewerID := app.sessionManager.GetInt(req.Context(), "authenticatedUserID")
+
+	snippet := app.viewableSnippet(writer, req, id, viewerID, mediaType)
+	if snippet == nil {
+		return
+	}
+
+	switch mediaType {
+	case "application/json":
+		app.writeJSON(writer, http.StatusOK, newAPISnippet(snippet), "application/json")
+		return
+	case "text/plain":
+		writeSnippetContent(writer, snippet)
+		return
+	}
+
+	app.renderSnippetView(writer, req, snippet, viewerID, http.StatusOK, commentForm{})
+}
+
+// renderSnippetView renders the page of snippet viewed by user with given
+// viewerID, with its revisions, forks and comments and form as the comment form
+func (app *application) renderSnippetView(writer http.ResponseWriter, req *http.Request, snippet *models.Snippet, viewerID, status int, form commentForm) {
+	revisions, err := app.snippets.Revisions(snippet.ID, viewerID)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	forks, err := app.snippets.Forks(snippet.ID, viewerID)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	comments, err := app.comments.Thread(snippet.ID)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	starred := false
+	if viewerID != 0 {
+		starred, err = app.snippets.HasStarred(snippet.ID, viewerID)
+		if err != nil {
+			app.serverError(writer, err)
+			return
+		}
+	}
+
+	version := len(revisions) + 1
+
+	code, err := newCodeView(snippet.Content, snippet.Language, version, comments)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	data := app.newTemplateData(req)
+	data.Snippet = snippet
+	data.Version = version
+	data.Code = code
+	data.Revisions = revisions
+	data.Forks = forks
+	data.Starred = starred
+	data.Comments = comments
+	data.Form = form
+
+	app.render(writer, status, "view.tmpl.html", data)
+}
+
+// snippetRaw serves snippet content as plain text, e.g. for piping it into a
+// shell. It follows the same rules as snippetView, so views are counted too.
+func (app *application) snippetRaw(writer http.ResponseWriter, req *http.Request) {
+	app.serveSnippetContent(writer, req, false)
+}
+
+// snippetDownload serves snippet content as a file named after its title
+func (app *application) snippetDownload(writer http.ResponseWriter, req *http.Request) {
+	app.serveSnippetContent(writer, req, true)
+}
+
+func (app *application) serveSnippetContent(writer http.ResponseWriter, req *http.Request, attachment bool) {
+	params := httprouter.ParamsFromContext(req.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(writer)
+		return
+	}
+
+	viewerID := app.sessionManager.GetInt(req.Context(), "authenticatedUserID")
+
+	snippet := app.viewableSnippet(writer, req, id, viewerID, "text/plain")
+	if snippet == nil {
+		return
+	}
+
+	if attachment {
+		writer.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+			"filename": snippetFilename(snippet),
+		}))
+	}
+
+	writeSnippetContent(writer, snippet)
+}
+
+// writeSnippetContent writes the content of snippet as plain text
+func writeSnippetContent(writer http.ResponseWriter, snippet *models.Snippet) {
+	// Content must never be sniffed as HTML, it's user supplied
+	writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(writer, snippet.Content)
+}
+
+// maxFilenameLength is the maximum length of snippet download file names,
+// excluding the extension
+const maxFilenameLength = 64
+
+// snippetFilename returns the download file name of snippet, its title reduced
+// to lowercase letters, digits, dots and dashes, followed by the extension of
+// its language unless the title already ends with it
+func snippetFilename(snippet *models.Snippet) string {
+	ext := highlight.Extension(snippet.Language)
+
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(snippet.Title) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.' || r == '_':
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+	}
+
+	name := strings.Trim(b.String(), ".")
+	if len(name) > maxFilenameLength {
+		name = strings.TrimRight(name[:maxFilenameLength], ".-")
+	}
+	if name == "" {
+		name = fmt.Sprintf("snippet-%d", snippet.ID)
+	}
+	if !strings.HasSuffix(name, ext) {
+		name += ext
+	}
+
+	return name
+}
+
+// viewableSnippet fetches snippet with given id for the viewer, counting it as
+// viewed. Locked snippets are answered as lockedSnippet does for mediaType, so
+// that no view is used up before the password is entered. On failure the
+// appropriate response is already written and nil is returned.
+func (app *application) viewableSnippet(writer http.ResponseWriter, req *http.Request, id, viewerID int, mediaType string) *models.Snippet {
+	if app.peekSnippetAs(writer, req, id, viewerID, mediaType) == nil {
+		return nil
+	}
+
+	snippet, err := app.snippets.Get(id, viewerID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(writer)
+			return nil
+		}
+		app.serverError(writer, err)
+		return nil
+	}
+
+	return snippet
+}
+
+// peekSnippet is like viewableSnippet, but it doesn't count a view, for
+// requests from pages that don't show the snippet content
+func (app *application) peekSnippet(writer http.ResponseWriter, req *http.Request, id, viewerID int) *models.Snippet {
+	return app.peekSnippetAs(writer, req, id, viewerID, "text/html")
+}
+
+// peekSnippetAs is like peekSnippet, answering locked snippets as lockedSnippet
+// does for mediaType
+func (app *application) peekSnippetAs(writer http.ResponseWriter, req *http.Request, id, viewerID int, mediaType string) *models.Snippet {
+	snippet, err := app.snippets.Peek(id, viewerID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(writer)
+			return nil
+		}
+		app.serverError(writer, err)
+		return nil
+	}
+
+	if app.locked(req, snippet) {
+		app.lockedSnippet(writer, req, id, mediaType)
+		return nil
+	}
+
+	return snippet
+}
+
+// lockedSnippet answers a request for snippet with given id that's locked for
+// the session. Browsers are redirected to the unlock page, other clients, e.g.
+// a shell piping raw content, get an error in the format they asked for, which
+// they can't take for the content.
+func (app *application) lockedSnippet(writer http.ResponseWriter, req *http.Request, id int, mediaType string) {
+	switch mediaType {
+	case "text/html":
+		http.Redirect(writer, req, fmt.Sprintf("/snippet/unlock/%d", id), http.StatusSeeOther)
+	case "application/json":
+		app.apiError(writer, http.StatusForbidden, fmt.Sprintf("The snippet is password protected, get it from /api/v1/snippets/%d passing the password in the %s header", id, apiPasswordHeader))
+	default:
+		http.Error(writer, "This snippet is password protected", http.StatusForbidden)
+	}
+}
+
+// peekable reports whether content of snippet may be shown to user with given
+// viewerID without counting a view. Only owners may do so with snippets whose
+// views are limited.
+func peekable(snippet *models.Snippet, viewerID int) bool {
+	return snippet.ViewsLeft == nil || snippet.UserID == viewerID
+}
+
+// snippetRevisionView shows a past version of a snippet. No view is counted,
+// so revisions of snippets with limited views are shown to their owner only.
+func (app *application) snippetRevisionView(writer http.ResponseWriter, req *http.Request) {
+	params := httprouter.ParamsFromContext(req.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(writer)
+		return
+	}
+
+	number, err := strconv.Atoi(params.ByName("n"))
+	if err != nil || number < 1 {
+		app.notFound(writer)
+		return
+	}
+
+	viewerID := app.sessionManager.GetInt(req.Context(), "authenticatedUserID")
+
+	snippet := app.peekSnippet(writer, req, id, viewerID)
+	if snippet == nil {
+		return
+	}
+
+	if !peekable(snippet, viewerID) {
+		app.clientError(writer, http.StatusForbidden)
+		return
+	}
+
+	revision, err := app.snippets.Revision(id, number, viewerID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(writer)
+			return
+		}
+		app.serverError(writer, err)
+		return
+	}
+
+	comments, err := app.comments.Thread(id)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	code, err := newCodeView(revision.Content, revision.Language, revision.Number, comments)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	data := app.newTemplateData(req)
+	data.Revision = revision
+	data.Code = code
+
+	app.render(writer, http.StatusOK, "revision.tmpl.html", data)
+}
+
+// versionContents returns the contents of all versions of snippet with given
+// revisions, contents[n-1] being the content of version n. The current version
+// goes last.
+func versionContents(revisions []*models.Revision, snippet *models.Snippet) []string {
+	var contents []string
+	for _, revision := range revisions {
+		contents = append(contents, revision.Content)
+	}
+	return append(contents, snippet.Content)
+}
+
+// diffContext is the number of unchanged lines shown around each change of a diff
+const diffContext = 3
+
+// snippetDiff shows changes between two versions of a snippet, picked with from
+// and to query parameters. By default the current version is compared with the
+// latest revision. Like revisions, diffs of snippets with limited views are
+// shown to their owner only.
+func (app *application) snippetDiff(writer http.ResponseWriter, req *http.Request) {
+	params := httprouter.ParamsFromContext(req.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(writer)
+		return
+	}
+
+	viewerID := app.sessionManager.GetInt(req.Context(), "authenticatedUserID")
+
+	snippet := app.peekSnippet(writer, req, id, viewerID)
+	if snippet == nil {
+		return
+	}
+
+	if !peekable(snippet, viewerID) {
+		app.clientError(writer, http.StatusForbidden)
+		return
+	}
+
+	revisions, err := app.snippets.Revisions(id, viewerID)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	contents := versionContents(revisions, snippet)
+
+	values := req.URL.Query()
+
+	view := &diffView{
+		SnippetID: id,
+		From:      len(contents) - 1,
+		To:        len(contents),
+		Latest:    len(contents),
+		Mode:      values.Get("mode"),
+	}
+	if view.From < 1 {
+		view.From = 1
+	}
+	if !validator.PermittedValue(view.Mode, "unified", "split") {
+		view.Mode = "unified"
+	}
+
+	versions := []struct {
+		param  string
+		number *int
+	}{
+		{"from", &view.From},
+		{"to", &view.To},
+	}
+	for _, version := range versions {
+		if !values.Has(version.param) {
+			continue
+		}
+
+		*version.number, err = strconv.Atoi(values.Get(version.param))
+		if err != nil {
+			app.clientError(writer, http.StatusBadRequest)
+			return
+		}
+		if *version.number < 1 || *version.number > len(contents) {
+			app.notFound(writer)
+			return
+		}
+	}
+
+	view.Hunks = diff.Hunks(diff.Lines(contents[view.From-1], contents[view.To-1]), diffContext)
+
+	if values.Get("format") == "patch" {
+		name := fmt.Sprintf("snippet-%d.txt", id)
+
+		writer.Header().Set("Content-Type", "text/x-patch; charset=utf-8")
+		writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="snippet-%d.patch"`, id))
+		writer.Write([]byte(diff.Unified("a/"+name, "b/"+name, view.Hunks)))
+		return
+	}
+
+	data := app.newTemplateData(req)
+	data.Diff = view
+
+	app.render(writer, http.StatusOK, "diff.tmpl.html", data)
+}
+
+func (app *application) search(writer http.ResponseWriter, req *http.Request) {
+	input := strings.TrimSpace(req.URL.Query().Get("q"))
+
+	data := app.newTemplateData(req)
+	data.Query = input
+
+	q, err := query.Parse(input)
+	if err != nil {
+		var qerr *query.Error
+		if errors.As(err, &qerr) {
+			app.renderSearchError(writer, data, qerr.Msg)
+		} else {
+			app.serverError(writer, err)
+		}
+		return
+	}
+
+	if !q.Empty() {
+		page, err := strconv.Atoi(req.URL.Query().Get("page"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+
+		data.SearchResults, err = app.snippets.Search(q, page)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidQuery) {
+				app.renderSearchError(writer, data, strings.TrimPrefix(err.Error(), models.ErrInvalidQuery.Error()+": "))
+			} else {
+				app.serverError(writer, err)
+			}
+			return
+		}
+
+		data.SearchWords = q.Words()
+		data.Listing = &listing{Page: page, TotalPages: data.SearchResults.TotalPages()}
+	}
+
+	app.render(writer, http.StatusOK, "search.tmpl.html", data)
+}
+
+// renderSearchError renders the search page with the reason the query can't
+// be run
+func (app *application) renderSearchError(writer http.ResponseWriter, data *templateData, reason string) {
+	form := &validator.Validator{}
+	form.AddGeneralError("Invalid search query: " + reason)
+	data.Form = form
+
+	app.render(writer, http.StatusUnprocessableEntity, "search.tmpl.html", data)
+}
+
+// tagCloudSize is the number of most used tags shown on the home page
+const tagCloudSize = 30
+
+const (
+	maxTags      = 5
+	maxTagLength = 32
+	maxViews     = 1000
+)
+
+var rxTag = regexp.MustCompile(`^[a-z0-9][a-z0-9+#.-]*$`)
+
+type snippetCreateForm struct {
+	Title   string `form:"title"`
+	Content string `form:"content"`
+	// Tags is a comma separated list of tags
+	Tags       string            `form:"tags"`
+	Language   string            `form:"language"`
+	Visibility models.Visibility `form:"visibility"`
+	// Expires is one of the configured expiry options or expiryCustom, in
+	// which case the expiration date is ExpiresAt
+	Expires   string `form:"expires"`
+	ExpiresAt string `form:"expires_at"`
+	// MaxViews is the number of views after which the snippet is deleted, 0
+	// for no limit
+	MaxViews int `form:"max_views"`
+	// Password protects the snippet when set. On edit, a blank password keeps
+	// the current one, unless RemovePassword is checked.
+	Password            string `form:"password"`
+	RemovePassword      bool   `form:"remove_password"`
+	validator.Validator `form:"-"`
+}
+
+// tagList returns tags entered into the form lowercased, without blanks and
+// duplicates
+func (form *snippetCreateForm) tagList() []string {
+	var tags []string
+
+	for _, tag := range strings.Split(form.Tags, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !validator.PermittedValue(tag, tags...) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// validTag reports whether tag can be used as a tag name
+func validTag(tag string) bool {
+	return validator.MaxChars(tag, maxTagLength) && validator.Matches(tag, rxTag)
+}
+
+// validate checks the form fields shared by snippet creation and edition
+func (form *snippetCreateForm) validate() {
+	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Title, 100), "title", "This field cannot exceed 100 characters")
+	form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be blank")
+
+	tags := form.tagList()
+	form.CheckField(len(tags) <= maxTags, "tags", fmt.Sprintf("This field cannot have more than %d tags", maxTags))
+	for _, tag := range tags {
+		if !validTag(tag) {
+			form.AddValidationError("tags", fmt.Sprintf("Tags can only contain letters, digits and + # . - characters, up to %d of them", maxTagLength))
+			break
+		}
+	}
+	form.CheckField(form.Language == "" || validator.PermittedValue(form.Language, highlight.Names()...), "language", "This field must be one of the supported languages")
+	form.CheckField(form.Password == "" || validator.MinChars(form.Password, 8), "password", "This field must be at least 8 characters long")
+	form.CheckField(validator.PermittedValue(form.Visibility, models.Visibilities...), "visibility", "This field must be one of public, unlisted or private")
+	form.CheckField(form.MaxViews >= 0 && form.MaxViews <= maxViews, "max_views", fmt.Sprintf("This field must be between 0 and %d", maxViews))
+}
+
+// checkExpiry validates the expiry fields and returns the expiration date of
+// the snippet, zero when it never expires. Only authenticated users can keep
+// snippets forever.
+func (form *snippetCreateForm) checkExpiry(expiry *expiryConfig, authenticated bool, now time.Time) time.Time {
+	expires, err := expiry.resolve(form.Expires, form.ExpiresAt, now)
+	if err != nil {
+		form.AddValidationError("expires", err.Error())
+	}
+	form.CheckField(form.Expires != expiryNever || authenticated, "expires", "Log in to keep snippets forever")
+
+	return expires
+}
+
+func (app *application) snippetCreate(writer http.ResponseWriter, req *http.Request) {
+	data := app.newTemplateData(req)
+	data.Form = snippetCreateForm{
+		Visibility: models.VisibilityPublic,
+		Expires:    app.expiry.Default(),
+	}
+
+	app.render(writer, http.StatusOK, "create.tmpl.html", data)
+}
+
+func (app *application) snippetCreatePost(writer http.ResponseWriter, req *http.Request) {
+	var form snippetCreateForm
+
+	err := app.decodePostForm(req, &form)
+	if err != nil {
+		app.clientError(writer, http.StatusBadRequest)
+		return
+	}
+
+	form.validate()
+	expires := form.checkExpiry(app.expiry, app.isAuthenticated(req), time.Now())
+
+	if !form.Valid() {
+		data := app.newTemplateData(req)
+		data.Form = form
+		app.render(writer, http.StatusUnprocessableEntity, "create.tmpl.html", data)
+		return
+	}
+
+	if form.Language == "" {
+		form.Language = langdetect.Detect(form.Title, form.Content)
+	}
+
+	userID := app.sessionManager.GetInt(req.Context(), "authenticatedUserID")
+
+	id, err := app.snippets.Insert(form.Title, form.Content, form.Language, form.tagList(), form.Visibility, form.Password, form.MaxViews, expires, userID, 0)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	app.sessionManager.Put(req.Context(), "flash", "Snippet successfully created!")
+
+	http.Redirect(writer, req, fmt.Sprintf("/snippet/view/%d", id), http.StatusSeeOther)
+}
+
+// forkableSnippet fetches snippet identified by the id route parameter for
+// forking. Snippets with limited views can only be forked by their owner, as
+// the fork form would show their content without counting a view. On failure
+// the appropriate response is already written and nil is returned.
+func (app *application) forkableSnippet(writer http.ResponseWriter, req *http.Request) *models.Snippet {
+	params := httprouter.ParamsFromContext(req.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(writer)
+		return nil
+	}
+
+	userID := app.sessionManager.GetInt(req.Context(), "authenticatedUserID")
+
+	snippet := app.peekSnippet(writer, req, id, userID)
+	if snippet == nil {
+		return nil
+	}
+
+	if !peekable(snippet, userID) {
+		app.clientError(writer, http.StatusForbidden)
+		return nil
+	}
+
+	return snippet
+}
+
+// snippetFork shows the form creating a copy of a snippet owned by the current
+// user, pre-filled from the source
+func (app *application) snippetFork(writer http.ResponseWriter, req *http.Request) {
+	source := app.forkableSnippet(writer, req)
+	if source == nil {
+		return
+	}
+
+	data := app.newTemplateData(req)
+	data.ForkedFrom = source
+	data.Form = snippetCreateForm{
+		Title:      source.Title,
+		Content:    source.Content,
+		Tags:       strings.Join(source.Tags, ", "),
+		Language:   source.Language,
+		Visibility: source.Visibility,
+		Expires:    app.expiry.Default(),
+	}
+
+	app.render(writer, http.StatusOK, "fork.tmpl.html", data)
+}
+
+func (app *application) snippetForkPost(writer http.ResponseWriter, req *http.Request) {
+	source := app.forkableSnippet(writer, req)
+	if source == nil {
+		return
+	}
+
+	var form snippetCreateForm
+
+	err := app.decodePostForm(req, &form)
+	if err != nil {
+		app.clientError(writer, http.StatusBadRequest)
+		return
+	}
+
+	form.validate()
+	expires := form.checkExpiry(app.expiry, true, time.Now())
+
+	if !form.Valid() {
+		data := app.newTemplateData(req)
+		data.ForkedFrom = source
+		data.Form = form
+		app.render(writer, http.StatusUnprocessableEntity, "fork.tmpl.html", data)
+		return
+	}
+
+	if form.Language == "" {
+		form.Language = langdetect.Detect(form.Title, form.Content)
+	}
+
+	userID := app.sessionManager.GetInt(req.Context(), "authenticatedUserID")
+
+	id, err := app.snippets.Insert(form.Title, form.Content, form.Language, form.tagList(), form.Visibility, form.Password, form.MaxViews, expires, userID, source.ID)
 	if err != nil {
 		app.serverError(writer, err)
 		return
 	}
 
-	data := app.newTemplateData(req)
-	data.Snippets = latestSnippets
+	app.sessionManager.Put(req.Context(), "flash", "Snippet successfully forked!")
 
-	app.render(writer, http.StatusOK, "home.tmpl.html", data)
+	http.Redirect(writer, req, fmt.Sprintf("/snippet/view/%d", id), http.StatusSeeOther)
 }
 
-func (app *application) snippetView(writer http.ResponseWriter, req *http.Request) {
+// ownedSnippet fetches snippet identified by the id route parameter and makes
+// sure it belongs to the authenticated user. Expired snippets are included, as
+// they're still listed on the account page. On failure the appropriate response
+// is already written and nil is returned.
+func (app *application) ownedSnippet(writer http.ResponseWriter, req *http.Request) *models.Snippet {
 	params := httprouter.ParamsFromContext(req.Context())
 
 	id, err := strconv.Atoi(params.ByName("id"))
 	if err != nil || id < 1 {
 		app.notFound(writer)
-		return
+		return nil
 	}
 
-	snippet, err := app.snippets.Get(id)
+	userID := app.sessionManager.GetInt(req.Context(), "authenticatedUserID")
+
+	snippet, err := app.snippets.Lookup(id, userID)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
 			app.notFound(writer)
-			return
+			return nil
 		}
 		app.serverError(writer, err)
+		return nil
+	}
+
+	if snippet.UserID != userID {
+		app.clientError(writer, http.StatusForbidden)
+		return nil
+	}
+
+	return snippet
+}
+
+func (app *application) snippetEdit(writer http.ResponseWriter, req *http.Request) {
+	snippet := app.ownedSnippet(writer, req)
+	if snippet == nil {
 		return
 	}
 
+	form := snippetCreateForm{
+		Title:      snippet.Title,
+		Content:    snippet.Content,
+		Tags:       strings.Join(snippet.Tags, ", "),
+		Language:   snippet.Language,
+		Visibility: snippet.Visibility,
+		Expires:    expiryNever,
+	}
+	if !snippet.Expires.IsZero() {
+		form.Expires = expiryCustom
+		form.ExpiresAt = snippet.Expires.UTC().Format(expiryDateLayout)
+	}
+	if snippet.ViewsLeft != nil {
+		form.MaxViews = *snippet.ViewsLeft
+	}
+
 	data := app.newTemplateData(req)
 	data.Snippet = snippet
+	data.Form = form
 
-	app.render(writer, http.StatusOK, "view.tmpl.html", data)
+	app.render(writer, http.StatusOK, "edit.tmpl.html", data)
 }
 
-type snippetCreateForm struct {
-	Title               string `form:"title"`
-	Content             string `form:"content"`
-	Expires             int    `form:"expires"`
+func (app *application) snippetEditPost(writer http.ResponseWriter, req *http.Request) {
+	snippet := app.ownedSnippet(writer, req)
+	if snippet == nil {
+		return
+	}
+
+	var form snippetCreateForm
+
+	err := app.decodePostForm(req, &form)
+	if err != nil {
+		app.clientError(writer, http.StatusBadRequest)
+		return
+	}
+
+	form.validate()
+	expires := form.checkExpiry(app.expiry, app.isAuthenticated(req), time.Now())
+
+	if !form.Valid() {
+		data := app.newTemplateData(req)
+		data.Snippet = snippet
+		data.Form = form
+		app.render(writer, http.StatusUnprocessableEntity, "edit.tmpl.html", data)
+		return
+	}
+
+	if form.Language == "" {
+		form.Language = langdetect.Detect(form.Title, form.Content)
+	}
+
+	var password *string
+	switch {
+	case form.RemovePassword:
+		password = new(string)
+	case form.Password != "":
+		password = &form.Password
+	}
+
+	err = app.snippets.Update(snippet.ID, form.Title, form.Content, form.Language, form.tagList(), form.Visibility, password, form.MaxViews, expires)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	app.sessionManager.Put(req.Context(), "flash", "Snippet successfully updated!")
+
+	http.Redirect(writer, req, fmt.Sprintf("/snippet/view/%d", snippet.ID), http.StatusSeeOther)
+}
+
+// unlockedKey is the session key remembering that the snippet with given id
+// was unlocked with its password
+func unlockedKey(id int) string {
+	return fmt.Sprintf("unlockedSnippet:%d", id)
+}
+
+// locked reports whether snippet is password protected and the current user
+// has yet to unlock it. Owners don't need to unlock their snippets.
+func (app *application) locked(req *http.Request, snippet *models.Snippet) bool {
+	if !snippet.Protected {
+		return false
+	}
+	if snippet.UserID == app.sessionManager.GetInt(req.Context(), "authenticatedUserID") {
+		return false
+	}
+	return !app.sessionManager.GetBool(req.Context(), unlockedKey(snippet.ID))
+}
+
+type snippetUnlockForm struct {
+	Password            string `form:"password"`
 	validator.Validator `form:"-"`
 }
 
-func (app *application) snippetCreate(writer http.ResponseWriter, req *http.Request) {
-	data := app.newTemplateData(req)
-	data.Form = snippetCreateForm{
-		Expires: 365,
+// unlockableSnippet fetches snippet identified by the id route parameter for
+// unlocking. On failure the appropriate response is already written and nil
+// is returned.
+func (app *application) unlockableSnippet(writer http.ResponseWriter, req *http.Request) *models.Snippet {
+	params := httprouter.ParamsFromContext(req.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(writer)
+		return nil
 	}
 
-	app.render(writer, http.StatusOK, "create.tmpl.html", data)
+	snippet, err := app.snippets.Peek(id, app.sessionManager.GetInt(req.Context(), "authenticatedUserID"))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(writer)
+			return nil
+		}
+		app.serverError(writer, err)
+		return nil
+	}
+
+	if !app.locked(req, snippet) {
+		http.Redirect(writer, req, fmt.Sprintf("/snippet/view/%d", id), http.StatusSeeOther)
+		return nil
+	}
+
+	return snippet
 }
 
-func (app *application) snippetCreatePost(writer http.ResponseWriter, req *http.Request) {
-	var form snippetCreateForm
+func (app *application) snippetUnlock(writer http.ResponseWriter, req *http.Request) {
+	snippet := app.unlockableSnippet(writer, req)
+	if snippet == nil {
+		return
+	}
+
+	data := app.newTemplateData(req)
+	data.Snippet = snippet
+	data.Form = snippetUnlockForm{}
+
+	app.render(writer, http.StatusOK, "unlock.tmpl.html", data)
+}
+
+func (app *application) snippetUnlockPost(writer http.ResponseWriter, req *http.Request) {
+	snippet := app.unlockableSnippet(writer, req)
+	if snippet == nil {
+		return
+	}
+
+	var form snippetUnlockForm
 
 	err := app.decodePostForm(req, &form)
 	if err != nil {
@@ -73,25 +935,77 @@ func (app *application) snippetCreatePost(writer http.ResponseWriter, req *http.
 		return
 	}
 
-	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank")
-	form.CheckField(validator.MaxChars(form.Title, 100), "title", "This field cannot exceed 100 characters")
-	form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be blank")
-	form.CheckField(validator.PermittedValue(form.Expires, 1, 7, 365), "expires", "This field must be equal one of these three values: [1,7,365]")
+	data := app.newTemplateData(req)
+	data.Snippet = snippet
 
-	if !form.Valid() {
-		data := app.newTemplateData(req)
+	if !app.unlockLimiter.Allow(snippet.ID) {
+		form.AddGeneralError("Too many wrong passwords, try again later")
 		data.Form = form
-		app.render(writer, http.StatusUnprocessableEntity, "create.tmpl.html", data)
+		app.render(writer, http.StatusTooManyRequests, "unlock.tmpl.html", data)
+		return
+	}
+
+	err = app.snippets.Unlock(snippet.ID, form.Password)
+	if !errors.Is(err, models.ErrInvalidCredentials) {
+		app.unlockLimiter.Release(snippet.ID)
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCredentials) {
+			form.AddGeneralError("Wrong password")
+			data.Form = form
+			app.render(writer, http.StatusUnprocessableEntity, "unlock.tmpl.html", data)
+		} else if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(writer)
+		} else {
+			app.serverError(writer, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(req.Context(), unlockedKey(snippet.ID), true)
+
+	http.Redirect(writer, req, fmt.Sprintf("/snippet/view/%d", snippet.ID), http.StatusSeeOther)
+}
+
+func (app *application) snippetDeletePost(writer http.ResponseWriter, req *http.Request) {
+	snippet := app.ownedSnippet(writer, req)
+	if snippet == nil {
 		return
 	}
 
-	id, err := app.snippets.Insert(form.Title, form.Content, form.Expires)
+	err := app.snippets.Delete(snippet.ID)
 	if err != nil {
 		app.serverError(writer, err)
 		return
 	}
 
-	app.sessionManager.Put(req.Context(), "flash", "Snippet successfully created!")
+	app.sessionManager.Put(req.Context(), "flash", "Snippet moved to trash")
+
+	http.Redirect(writer, req, "/account/trash", http.StatusSeeOther)
+}
+
+func (app *application) snippetRestorePost(writer http.ResponseWriter, req *http.Request) {
+	params := httprouter.ParamsFromContext(req.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(writer)
+		return
+	}
+
+	userID := app.sessionManager.GetInt(req.Context(), "authenticatedUserID")
+
+	err = app.snippets.Restore(id, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(writer)
+			return
+		}
+		app.serverError(writer, err)
+		return
+	}
+
+	app.sessionManager.Put(req.Context(), "flash", "Snippet successfully restored!")
 
 	http.Redirect(writer, req, fmt.Sprintf("/snippet/view/%d", id), http.StatusSeeOther)
 }
@@ -237,6 +1151,9 @@ func (app *application) about(writer http.ResponseWriter, request *http.Request)
 	app.render(writer, http.StatusOK, "about.tmpl.html", data)
 }
 
+// accountSnippetsPageSize is the number of snippets shown per page of the account view
+const accountSnippetsPageSize = 10
+
 func (app *application) accountView(writer http.ResponseWriter, request *http.Request) {
 	id := app.sessionManager.GetInt(request.Context(), "authenticatedUserID")
 
@@ -250,12 +1167,61 @@ func (app *application) accountView(writer http.ResponseWriter, request *http.Re
 		return
 	}
 
+	values := request.URL.Query()
+
+	list := &listing{
+		Status: values.Get("status"),
+		Sort:   values.Get("sort"),
+	}
+	if !validator.PermittedValue(list.Status, models.StatusAll, models.StatusActive, models.StatusExpired) {
+		list.Status = models.StatusAll
+	}
+	if !validator.PermittedValue(list.Sort, models.SortCreated, models.SortExpires, models.SortStars) {
+		list.Sort = models.SortCreated
+	}
+
+	list.Page, err = strconv.Atoi(values.Get("page"))
+	if err != nil || list.Page < 1 {
+		list.Page = 1
+	}
+
+	snippets, counts, err := app.snippets.ListByOwner(id, models.OwnerFilter{
+		Status:   list.Status,
+		Sort:     list.Sort,
+		Page:     list.Page,
+		PageSize: accountSnippetsPageSize,
+	})
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	list.TotalPages = (counts.Matching(list.Status) + accountSnippetsPageSize - 1) / accountSnippetsPageSize
+
 	data := app.newTemplateData(request)
 	data.User = user
+	data.Snippets = snippets
+	data.SnippetCounts = counts
+	data.Listing = list
 
 	app.render(writer, http.StatusOK, "account.tmpl.html", data)
 }
 
+func (app *application) accountTrash(writer http.ResponseWriter, request *http.Request) {
+	id := app.sessionManager.GetInt(request.Context(), "authenticatedUserID")
+
+	snippets, err := app.snippets.Trash(id)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	data := app.newTemplateData(request)
+	data.Snippets = snippets
+
+	app.render(writer, http.StatusOK, "trash.tmpl.html", data)
+}
+
 type accountPasswordUpdateForm struct {
 	CurrentPassword     string `form:"currentPassword"`
 	NewPassword         string `form:"newPassword"`
@@ -310,3 +1276,88 @@ func (app *application) accountPasswordUpdatePost(writer http.ResponseWriter, re
 	app.sessionManager.Put(request.Context(), "flash", "Password changed successfully")
 	http.Redirect(writer, request, "/account/view", http.StatusSeeOther)
 }
+
+type tokenCreateForm struct {
+	Name                string            `form:"name"`
+	Scope               models.TokenScope `form:"scope"`
+	validator.Validator `form:"-"`
+}
+
+// renderTokens renders the token management page with given form, along with
+// tokens of the authenticated user
+func (app *application) renderTokens(writer http.ResponseWriter, request *http.Request, status int, form tokenCreateForm) {
+	id := app.sessionManager.GetInt(request.Context(), "authenticatedUserID")
+
+	tokens, err := app.tokens.ListByOwner(id)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	data := app.newTemplateData(request)
+	data.Tokens = tokens
+	data.NewToken = app.sessionManager.PopString(request.Context(), "newToken")
+	data.Form = form
+
+	app.render(writer, status, "tokens.tmpl.html", data)
+}
+
+func (app *application) accountTokens(writer http.ResponseWriter, request *http.Request) {
+	app.renderTokens(writer, request, http.StatusOK, tokenCreateForm{Scope: models.TokenScopeRead})
+}
+
+func (app *application) accountTokensPost(writer http.ResponseWriter, request *http.Request) {
+	var form tokenCreateForm
+
+	err := app.decodePostForm(request, &form)
+	if err != nil {
+		app.clientError(writer, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Name), "name", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Name, 100), "name", "This field cannot exceed 100 characters")
+	form.CheckField(validator.PermittedValue(form.Scope, models.TokenScopes...), "scope", "This field must be either read or write")
+
+	if !form.Valid() {
+		app.renderTokens(writer, request, http.StatusUnprocessableEntity, form)
+		return
+	}
+
+	id := app.sessionManager.GetInt(request.Context(), "authenticatedUserID")
+
+	token, err := app.tokens.Insert(id, form.Name, form.Scope)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	app.sessionManager.Put(request.Context(), "newToken", token)
+	app.sessionManager.Put(request.Context(), "flash", "Token created, copy it now as it won't be shown again")
+	http.Redirect(writer, request, "/account/tokens", http.StatusSeeOther)
+}
+
+func (app *application) accountTokenRevokePost(writer http.ResponseWriter, request *http.Request) {
+	params := httprouter.ParamsFromContext(request.Context())
+
+	tokenID, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || tokenID < 1 {
+		app.notFound(writer)
+		return
+	}
+
+	id := app.sessionManager.GetInt(request.Context(), "authenticatedUserID")
+
+	err = app.tokens.Revoke(tokenID, id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(writer)
+			return
+		}
+		app.serverError(writer, err)
+		return
+	}
+
+	app.sessionManager.Put(request.Context(), "flash", "Token revoked")
+	http.Redirect(writer, request, "/account/tokens", http.StatusSeeOther)
+}
@@ -4,7 +4,11 @@ import (
 	"net/http"
 	"net/url"
 	"snippetbox/internal/assert"
+	"snippetbox/internal/models"
+	"snippetbox/internal/models/mocks"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestPing(t *testing.T) {
@@ -18,6 +22,128 @@ func TestPing(t *testing.T) {
 	assert.Equal(t, body, "OK")
 }
 
+func TestHome(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Newest",
+			urlPath:  "/",
+			wantCode: http.StatusOK,
+			wantBody: "<a href='/snippet/view/1'>An old silent pond...</a>",
+		},
+		{
+			name:     "Comment and star counts",
+			urlPath:  "/",
+			wantCode: http.StatusOK,
+			wantBody: "<td>4</td>\n                    <td>2</td>",
+		},
+		{
+			name:     "Tag cloud",
+			urlPath:  "/",
+			wantCode: http.StatusOK,
+			wantBody: "<a href='/tag/haiku' class='level-1' title='1 snippets'>haiku</a>",
+		},
+		{
+			name:     "Older page",
+			urlPath:  "/?before=2",
+			wantCode: http.StatusOK,
+			wantBody: "<a href='/?after=1'>&laquo; Newer</a>",
+		},
+		{
+			name:     "Past the oldest",
+			urlPath:  "/?before=1",
+			wantCode: http.StatusOK,
+			wantBody: "There's nothing to see here... yet!",
+		},
+		{
+			name:     "Invalid cursor",
+			urlPath:  "/?after=foo",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Both cursors",
+			urlPath:  "/?before=2&after=1",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestTagView(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Tagged snippets",
+			urlPath:  "/tag/haiku",
+			wantCode: http.StatusOK,
+			wantBody: "<a href='/snippet/view/1'>An old silent pond...</a>",
+		},
+		{
+			name:     "Uppercase",
+			urlPath:  "/tag/Haiku",
+			wantCode: http.StatusOK,
+			wantBody: "<a href='/snippet/view/1'>An old silent pond...</a>",
+		},
+		{
+			name:     "Unused tag",
+			urlPath:  "/tag/c%23",
+			wantCode: http.StatusOK,
+			wantBody: "There are no snippets tagged c#.",
+		},
+		{
+			name:     "Invalid tag",
+			urlPath:  "/tag/two%20words",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Invalid cursor",
+			urlPath:  "/tag/haiku?before=0",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
 func TestSnippetView(t *testing.T) {
 	app := newTestApplication(t)
 
@@ -36,6 +162,53 @@ func TestSnippetView(t *testing.T) {
 			wantCode: http.StatusOK,
 			wantBody: "An old silent pond...",
 		},
+		{
+			name:     "Author",
+			urlPath:  "/snippet/view/1",
+			wantCode: http.StatusOK,
+			wantBody: "by test",
+		},
+		{
+			name:     "Tags",
+			urlPath:  "/snippet/view/1",
+			wantCode: http.StatusOK,
+			wantBody: "<a href='/tag/haiku'>haiku</a>",
+		},
+		{
+			name:     "Line numbers",
+			urlPath:  "/snippet/view/1",
+			wantCode: http.StatusOK,
+			wantBody: "<span class='line' id='L1'>",
+		},
+		{
+			name:     "Unlisted",
+			urlPath:  "/snippet/view/3",
+			wantCode: http.StatusOK,
+			wantBody: "<em class='visibility'>unlisted</em>",
+		},
+		{
+			name:     "Private",
+			urlPath:  "/snippet/view/5",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Burn after reading",
+			urlPath:  "/snippet/view/7",
+			wantCode: http.StatusOK,
+			wantBody: "This snippet has been deleted after this view",
+		},
+		{
+			name:     "Fork",
+			urlPath:  "/snippet/view/8",
+			wantCode: http.StatusOK,
+			wantBody: "forked from <a href='/snippet/view/3'>#3</a>",
+		},
+		{
+			name:     "Forks",
+			urlPath:  "/snippet/view/3",
+			wantCode: http.StatusOK,
+			wantBody: "<a href='/snippet/view/8'>Over the wintry forest, again</a>",
+		},
 		{
 			name:     "Non-existent ID",
 			urlPath:  "/snippet/view/2",
@@ -73,152 +246,1341 @@ func TestSnippetView(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("Private by owner", func(t *testing.T) {
+		ts.login(t)
+
+		code, _, body := ts.get(t, "/snippet/view/5")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "<em class='visibility'>private</em>")
+		assert.StringContains(t, body, "<a href='/snippet/fork/5'>Fork</a>")
+	})
 }
 
-func TestUserSignup(t *testing.T) {
+func TestSnippetViewNegotiation(t *testing.T) {
 	app := newTestApplication(t)
 
 	ts := newTestServer(t, app.routes())
 	defer ts.Close()
 
-	_, _, body := ts.get(t, "/user/signup")
-	validCSRFToken := extractCSRFToken(t, body)
-
-	const (
-		validName     = "Bob"
-		validPassword = "validPa$$word"
-		validEmail    = "bob@example.com"
-		formTag       = "<form action='/user/signup' method='POST' novalidate>"
-	)
-
 	tests := []struct {
-		name         string
-		userName     string
-		userEmail    string
-		userPassword string
-		csrfToken    string
-		wantCode     int
-		wantFormTag  string
+		name            string
+		urlPath         string
+		accept          string
+		wantCode        int
+		wantContentType string
+		wantBody        string
 	}{
 		{
-			name:         "Valid submission",
-			userName:     validName,
-			userEmail:    validEmail,
-			userPassword: validPassword,
-			csrfToken:    validCSRFToken,
-			wantCode:     http.StatusSeeOther,
+			name:            "Browser",
+			urlPath:         "/snippet/view/1",
+			accept:          "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			wantCode:        http.StatusOK,
+			wantContentType: "text/html; charset=utf-8",
+			wantBody:        "<span class='line' id='L1'>",
 		},
 		{
-			name:         "Invalid CSRF Token",
-			userName:     validName,
-			userEmail:    validEmail,
-			userPassword: validPassword,
-			csrfToken:    "wrongToken",
-			wantCode:     http.StatusBadRequest,
+			name:            "Any",
+			urlPath:         "/snippet/view/1",
+			accept:          "*/*",
+			wantCode:        http.StatusOK,
+			wantContentType: "text/html; charset=utf-8",
 		},
 		{
-			name:         "Empty name",
-			userName:     "",
-			userEmail:    validEmail,
-			userPassword: validPassword,
-			csrfToken:    validCSRFToken,
-			wantCode:     http.StatusUnprocessableEntity,
-			wantFormTag:  formTag,
+			name:            "JSON",
+			urlPath:         "/snippet/view/1",
+			accept:          "application/json",
+			wantCode:        http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        `"title":"An old silent pond..."`,
 		},
 		{
-			name:         "Empty email",
-			userName:     validName,
-			userEmail:    "",
-			userPassword: validPassword,
-			csrfToken:    validCSRFToken,
-			wantCode:     http.StatusUnprocessableEntity,
-			wantFormTag:  formTag,
+			name:            "Plain text",
+			urlPath:         "/snippet/view/1",
+			accept:          "text/plain",
+			wantCode:        http.StatusOK,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "An old silent pond...",
 		},
 		{
-			name:         "Empty password",
-			userName:     validName,
-			userEmail:    validEmail,
-			userPassword: "",
-			csrfToken:    validCSRFToken,
-			wantCode:     http.StatusUnprocessableEntity,
-			wantFormTag:  formTag,
+			name:            "Preferred",
+			urlPath:         "/snippet/view/1",
+			accept:          "text/html;q=0.5, application/json",
+			wantCode:        http.StatusOK,
+			wantContentType: "application/json",
 		},
 		{
-			name:         "Invalid email",
-			userName:     validName,
-			userEmail:    "bob@example.",
-			userPassword: validPassword,
-			csrfToken:    validCSRFToken,
-			wantCode:     http.StatusUnprocessableEntity,
-			wantFormTag:  formTag,
+			name:            "Unacceptable",
+			urlPath:         "/snippet/view/1",
+			accept:          "image/png",
+			wantCode:        http.StatusOK,
+			wantContentType: "text/html; charset=utf-8",
 		},
 		{
-			name:         "Short password",
-			userName:     validName,
-			userEmail:    validEmail,
-			userPassword: "pa$$",
-			csrfToken:    validCSRFToken,
-			wantCode:     http.StatusUnprocessableEntity,
-			wantFormTag:  formTag,
+			name:     "Private",
+			urlPath:  "/snippet/view/5",
+			accept:   "application/json",
+			wantCode: http.StatusNotFound,
 		},
 		{
-			name:         "Duplicate email",
-			userName:     validName,
-			userEmail:    "duplicate@email.com",
-			userPassword: validPassword,
-			csrfToken:    validCSRFToken,
-			wantCode:     http.StatusUnprocessableEntity,
-			wantFormTag:  formTag,
+			name:            "Protected browser",
+			urlPath:         "/snippet/view/6",
+			accept:          "text/html",
+			wantCode:        http.StatusSeeOther,
+			wantContentType: "text/html; charset=utf-8",
+		},
+		{
+			name:            "Protected JSON",
+			urlPath:         "/snippet/view/6",
+			accept:          "application/json",
+			wantCode:        http.StatusForbidden,
+			wantContentType: "application/problem+json",
+			wantBody:        `"detail":"The snippet is password protected`,
+		},
+		{
+			name:            "Protected plain text",
+			urlPath:         "/snippet/view/6",
+			accept:          "text/plain",
+			wantCode:        http.StatusForbidden,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "This snippet is password protected",
 		},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			form := url.Values{}
-			form.Add("name", tt.userName)
-			form.Add("email", tt.userEmail)
-			form.Add("password", tt.userPassword)
-			form.Add("csrf_token", tt.csrfToken)
-
-			code, _, body := ts.postForm(t, "/user/signup", form)
+			code, header, body := ts.getAccept(t, tt.urlPath, tt.accept)
 
 			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, strings.Join(header.Values("Vary"), ", "), "Accept")
 
-			if tt.wantFormTag != "" {
-				assert.StringContains(t, body, tt.wantFormTag)
+			if tt.wantContentType != "" {
+				assert.Equal(t, header.Get("Content-Type"), tt.wantContentType)
+			}
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
 			}
 		})
 	}
 }
 
-func TestSnippetCreate(t *testing.T) {
+func TestSnippetRaw(t *testing.T) {
 	app := newTestApplication(t)
 
 	ts := newTestServer(t, app.routes())
 	defer ts.Close()
 
-	t.Run("Unauthenticated", func(t *testing.T) {
-		statusCode, header, _ := ts.get(t, "/snippet/create")
-
-		assert.Equal(t, statusCode, http.StatusSeeOther)
-		assert.Equal(t, header.Get("Location"), "/user/login")
-	})
-
-	t.Run("Authenticated", func(t *testing.T) {
-		_, _, body := ts.get(t, "/user/login")
-		csrfToken := extractCSRFToken(t, body)
+	tests := []struct {
+		name            string
+		urlPath         string
+		wantCode        int
+		wantBody        string
+		wantDisposition string
+		wantLocation    string
+	}{
+		{
+			name:     "Raw",
+			urlPath:  "/snippet/raw/1",
+			wantCode: http.StatusOK,
+			wantBody: "An old silent pond...",
+		},
+		{
+			name:            "Download",
+			urlPath:         "/snippet/download/1",
+			wantCode:        http.StatusOK,
+			wantBody:        "An old silent pond...",
+			wantDisposition: `attachment; filename=an-old-silent-pond.txt`,
+		},
+		{
+			name:     "Private",
+			urlPath:  "/snippet/raw/5",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Protected",
+			urlPath:  "/snippet/download/6",
+			wantCode: http.StatusForbidden,
+			wantBody: "This snippet is password protected",
+		},
+		{
+			name:     "Protected raw",
+			urlPath:  "/snippet/raw/6",
+			wantCode: http.StatusForbidden,
+			wantBody: "This snippet is password protected",
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/snippet/raw/2",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "String ID",
+			urlPath:  "/snippet/download/foo",
+			wantCode: http.StatusNotFound,
+		},
+	}
 
-		form := url.Values{}
-		form.Add("email", "test@email.com")
-		form.Add("password", "password")
-		form.Add("csrf_token", csrfToken)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, header, body := ts.get(t, tt.urlPath)
 
-		ts.postForm(t, "/user/login", form)
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Content-Disposition"), tt.wantDisposition)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
 
-		statusCode, _, body := ts.get(t, "/snippet/create")
+			if tt.wantBody != "" {
+				assert.Equal(t, header.Get("Content-Type"), "text/plain; charset=utf-8")
+				assert.Equal(t, body, tt.wantBody)
+			}
+		})
+	}
+}
 
-		assert.Equal(t, statusCode, http.StatusOK)
+func TestSnippetFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		language string
+		want     string
+	}{
+		{
+			name:     "Words",
+			title:    "Bootstrap the  build box!",
+			language: "bash",
+			want:     "bootstrap-the-build-box.sh",
+		},
+		{
+			name:     "Extension in title",
+			title:    "bootstrap.sh",
+			language: "bash",
+			want:     "bootstrap.sh",
+		},
+		{
+			name:  "No language",
+			title: "Notes",
+			want:  "notes.txt",
+		},
+		{
+			name:     "Nothing left",
+			title:    "日本語",
+			language: "go",
+			want:     "snippet-7.go",
+		},
+		{
+			name:     "Long title",
+			title:    strings.Repeat("a", 70),
+			language: "go",
+			want:     strings.Repeat("a", 64) + ".go",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet := &models.Snippet{ID: 7, Title: tt.title, Language: tt.language}
+
+			assert.Equal(t, snippetFilename(snippet), tt.want)
+		})
+	}
+}
+
+func TestSnippetUnlock(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	for _, urlPath := range []string{"/snippet/view/6", "/snippet/view/6/rev/1", "/snippet/diff/6"} {
+		code, header, _ := ts.get(t, urlPath)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/snippet/unlock/6")
+	}
+
+	code, header, _ := ts.get(t, "/snippet/unlock/1")
+	assert.Equal(t, code, http.StatusSeeOther)
+	assert.Equal(t, header.Get("Location"), "/snippet/view/1")
+
+	code, _, body := ts.get(t, "/snippet/unlock/6")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, "<form action='/snippet/unlock/6' method='POST' novalidate>")
+	assert.Equal(t, strings.Contains(body, "hunter2"), false)
+
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string
+		password     string
+		wantCode     int
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:     "Wrong password",
+			password: "sesame",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "Wrong password",
+		},
+		{
+			name:         "Valid password",
+			password:     "open sesame",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/6",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("password", tt.password)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, header, body := ts.postForm(t, "/snippet/unlock/6", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("Unlocked", func(t *testing.T) {
+		code, _, body := ts.get(t, "/snippet/view/6")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "hunter2")
+	})
+}
+
+func TestSnippetUnlockRateLimit(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/snippet/unlock/6")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	unlock := func(password string) int {
+		form := url.Values{}
+		form.Add("password", password)
+		form.Add("csrf_token", validCSRFToken)
+
+		code, _, _ := ts.postForm(t, "/snippet/unlock/6", form)
+		return code
+	}
+
+	for i := 0; i < unlockMaxFailures; i++ {
+		assert.Equal(t, unlock("wrong"), http.StatusUnprocessableEntity)
+	}
+
+	assert.Equal(t, unlock("open sesame"), http.StatusTooManyRequests)
+}
+
+func TestUserSignup(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	_, _, body := ts.get(t, "/user/signup")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	const (
+		validName     = "Bob"
+		validPassword = "validPa$$word"
+		validEmail    = "bob@example.com"
+		formTag       = "<form action='/user/signup' method='POST' novalidate>"
+	)
+
+	tests := []struct {
+		name         string
+		userName     string
+		userEmail    string
+		userPassword string
+		csrfToken    string
+		wantCode     int
+		wantFormTag  string
+	}{
+		{
+			name:         "Valid submission",
+			userName:     validName,
+			userEmail:    validEmail,
+			userPassword: validPassword,
+			csrfToken:    validCSRFToken,
+			wantCode:     http.StatusSeeOther,
+		},
+		{
+			name:         "Invalid CSRF Token",
+			userName:     validName,
+			userEmail:    validEmail,
+			userPassword: validPassword,
+			csrfToken:    "wrongToken",
+			wantCode:     http.StatusBadRequest,
+		},
+		{
+			name:         "Empty name",
+			userName:     "",
+			userEmail:    validEmail,
+			userPassword: validPassword,
+			csrfToken:    validCSRFToken,
+			wantCode:     http.StatusUnprocessableEntity,
+			wantFormTag:  formTag,
+		},
+		{
+			name:         "Empty email",
+			userName:     validName,
+			userEmail:    "",
+			userPassword: validPassword,
+			csrfToken:    validCSRFToken,
+			wantCode:     http.StatusUnprocessableEntity,
+			wantFormTag:  formTag,
+		},
+		{
+			name:         "Empty password",
+			userName:     validName,
+			userEmail:    validEmail,
+			userPassword: "",
+			csrfToken:    validCSRFToken,
+			wantCode:     http.StatusUnprocessableEntity,
+			wantFormTag:  formTag,
+		},
+		{
+			name:         "Invalid email",
+			userName:     validName,
+			userEmail:    "bob@example.",
+			userPassword: validPassword,
+			csrfToken:    validCSRFToken,
+			wantCode:     http.StatusUnprocessableEntity,
+			wantFormTag:  formTag,
+		},
+		{
+			name:         "Short password",
+			userName:     validName,
+			userEmail:    validEmail,
+			userPassword: "pa$$",
+			csrfToken:    validCSRFToken,
+			wantCode:     http.StatusUnprocessableEntity,
+			wantFormTag:  formTag,
+		},
+		{
+			name:         "Duplicate email",
+			userName:     validName,
+			userEmail:    "duplicate@email.com",
+			userPassword: validPassword,
+			csrfToken:    validCSRFToken,
+			wantCode:     http.StatusUnprocessableEntity,
+			wantFormTag:  formTag,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("name", tt.userName)
+			form.Add("email", tt.userEmail)
+			form.Add("password", tt.userPassword)
+			form.Add("csrf_token", tt.csrfToken)
+
+			code, _, body := ts.postForm(t, "/user/signup", form)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantFormTag != "" {
+				assert.StringContains(t, body, tt.wantFormTag)
+			}
+		})
+	}
+}
+
+func TestSnippetCreate(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		statusCode, header, _ := ts.get(t, "/snippet/create")
+
+		assert.Equal(t, statusCode, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/user/login")
+	})
+
+	t.Run("Authenticated", func(t *testing.T) {
+		_, _, body := ts.get(t, "/user/login")
+		csrfToken := extractCSRFToken(t, body)
+
+		form := url.Values{}
+		form.Add("email", "test@email.com")
+		form.Add("password", "password")
+		form.Add("csrf_token", csrfToken)
+
+		ts.postForm(t, "/user/login", form)
+
+		statusCode, _, body := ts.get(t, "/snippet/create")
+
+		assert.Equal(t, statusCode, http.StatusOK)
 		assert.StringContains(t, body, "<form action='/snippet/create' method='POST'>")
 	})
 
 }
+
+func TestSnippetCreatePost(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.login(t)
+
+	_, _, body := ts.get(t, "/snippet/create")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string
+		title        string
+		content      string
+		tags         string
+		language     string
+		visibility   string
+		password     string
+		maxViews     string
+		expires      string
+		expiresAt    string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Valid submission",
+			title:        "O snail",
+			content:      "Climb Mount Fuji",
+			visibility:   "public",
+			expires:      "1w",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+		},
+		{
+			name:         "Valid language",
+			title:        "O snail",
+			content:      "Climb Mount Fuji",
+			language:     "go",
+			visibility:   "public",
+			expires:      "1w",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+		},
+		{
+			name:       "Invalid language",
+			title:      "O snail",
+			content:    "Climb Mount Fuji",
+			language:   "brainfuck",
+			visibility: "public",
+			expires:    "1w",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Valid tags",
+			title:        "O snail",
+			content:      "Climb Mount Fuji",
+			tags:         " Haiku, c++ ,,haiku",
+			visibility:   "public",
+			expires:      "1w",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+		},
+		{
+			name:       "Invalid tag",
+			title:      "O snail",
+			content:    "Climb Mount Fuji",
+			tags:       "haiku, two words",
+			visibility: "public",
+			expires:    "1w",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Too many tags",
+			title:      "O snail",
+			content:    "Climb Mount Fuji",
+			tags:       "a, b, c, d, e, f",
+			visibility: "public",
+			expires:    "1w",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Private",
+			title:        "O snail",
+			content:      "Climb Mount Fuji",
+			visibility:   "private",
+			expires:      "1w",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+		},
+		{
+			name:         "Password",
+			title:        "O snail",
+			content:      "Climb Mount Fuji",
+			visibility:   "unlisted",
+			password:     "open sesame",
+			expires:      "1w",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+		},
+		{
+			name:       "Short password",
+			title:      "O snail",
+			content:    "Climb Mount Fuji",
+			visibility: "unlisted",
+			password:   "sesame",
+			expires:    "1w",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Burn after reading",
+			title:        "O snail",
+			content:      "Climb Mount Fuji",
+			visibility:   "unlisted",
+			maxViews:     "1",
+			expires:      "1w",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+		},
+		{
+			name:       "Invalid max views",
+			title:      "O snail",
+			content:    "Climb Mount Fuji",
+			visibility: "unlisted",
+			maxViews:   "-1",
+			expires:    "1w",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Invalid visibility",
+			title:      "O snail",
+			content:    "Climb Mount Fuji",
+			visibility: "secret",
+			expires:    "1w",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Empty title",
+			content:    "Climb Mount Fuji",
+			visibility: "public",
+			expires:    "1w",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Invalid expires",
+			title:      "O snail",
+			content:    "Climb Mount Fuji",
+			visibility: "public",
+			expires:    "3",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Never expires",
+			title:        "O snail",
+			content:      "Climb Mount Fuji",
+			visibility:   "public",
+			expires:      "never",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+		},
+		{
+			name:         "Custom expiry",
+			title:        "O snail",
+			content:      "Climb Mount Fuji",
+			visibility:   "public",
+			expires:      "custom",
+			expiresAt:    time.Now().UTC().Add(48 * time.Hour).Format("2006-01-02T15:04"),
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+		},
+		{
+			name:       "Past custom expiry",
+			title:      "O snail",
+			content:    "Climb Mount Fuji",
+			visibility: "public",
+			expires:    "custom",
+			expiresAt:  "2020-01-01T10:00",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Invalid custom expiry",
+			title:      "O snail",
+			content:    "Climb Mount Fuji",
+			visibility: "public",
+			expires:    "custom",
+			expiresAt:  "tomorrow",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("title", tt.title)
+			form.Add("content", tt.content)
+			form.Add("tags", tt.tags)
+			form.Add("language", tt.language)
+			form.Add("visibility", tt.visibility)
+			form.Add("password", tt.password)
+			form.Add("max_views", tt.maxViews)
+			form.Add("expires", tt.expires)
+			form.Add("expires_at", tt.expiresAt)
+			form.Add("csrf_token", validCSRFToken)
+
+			code, header, _ := ts.postForm(t, "/snippet/create", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+		})
+	}
+}
+
+func TestAccountView(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		statusCode, header, _ := ts.get(t, "/account/view")
+
+		assert.Equal(t, statusCode, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/user/login")
+	})
+
+	ts.login(t)
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantBody string
+	}{
+		{
+			name:     "Default listing",
+			urlPath:  "/account/view",
+			wantBody: "An old silent pond...",
+		},
+		{
+			name:     "Counts",
+			urlPath:  "/account/view",
+			wantBody: "Active (1)",
+		},
+		{
+			name:     "Expired filter",
+			urlPath:  "/account/view?status=expired",
+			wantBody: "You haven't created any snippets matching this filter yet.",
+		},
+		{
+			name:     "Stars sort",
+			urlPath:  "/account/view?sort=stars",
+			wantBody: `<a href='/account/view?status=all&sort=stars' class='live'>Stars</a>`,
+		},
+		{
+			name:     "Invalid filter",
+			urlPath:  "/account/view?status=foo&sort=bar&page=-1",
+			wantBody: "Page 1 of 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, http.StatusOK)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestAccountTokens(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, header, _ := ts.get(t, "/account/tokens")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/user/login")
+	})
+
+	ts.login(t)
+
+	code, _, body := ts.get(t, "/account/tokens")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, "<td>CI</td>")
+	csrfToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string
+		tokenName    string
+		scope        string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Valid submission",
+			tokenName:    "CI",
+			scope:        "write",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/account/tokens",
+		},
+		{
+			name:     "Empty name",
+			scope:    "read",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid scope",
+			tokenName: "CI",
+			scope:     "admin",
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("name", tt.tokenName)
+			form.Add("scope", tt.scope)
+			form.Add("csrf_token", csrfToken)
+
+			code, header, _ := ts.postForm(t, "/account/tokens", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+		})
+	}
+
+	t.Run("Shown once", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("name", "CI")
+		form.Add("scope", "read")
+		form.Add("csrf_token", csrfToken)
+		ts.postForm(t, "/account/tokens", form)
+
+		_, _, body := ts.get(t, "/account/tokens")
+		assert.StringContains(t, body, "<code>"+mocks.MockWriteToken+"</code>")
+
+		_, _, body = ts.get(t, "/account/tokens")
+		if strings.Contains(body, mocks.MockWriteToken) {
+			t.Errorf("token shown again")
+		}
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("csrf_token", csrfToken)
+
+		code, header, _ := ts.postForm(t, "/account/tokens/revoke/1", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/account/tokens")
+
+		code, _, _ = ts.postForm(t, "/account/tokens/revoke/2", form)
+		assert.Equal(t, code, http.StatusNotFound)
+	})
+}
+
+func TestSnippetEdit(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		statusCode, header, _ := ts.get(t, "/snippet/edit/1")
+
+		assert.Equal(t, statusCode, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/user/login")
+	})
+
+	ts.login(t)
+
+	getTests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Owner",
+			urlPath:  "/snippet/edit/1",
+			wantCode: http.StatusOK,
+			wantBody: "<form action='/snippet/edit/1' method='POST'>",
+		},
+		{
+			name:     "Private",
+			urlPath:  "/snippet/edit/5",
+			wantCode: http.StatusOK,
+			wantBody: "<input type='radio' name='visibility' value='private' checked>",
+		},
+		{
+			name:     "Expired",
+			urlPath:  "/snippet/edit/9",
+			wantCode: http.StatusOK,
+			wantBody: "<form action='/snippet/edit/9' method='POST'>",
+		},
+		{
+			name:     "Not owner",
+			urlPath:  "/snippet/edit/3",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/snippet/edit/2",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range getTests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+
+	_, _, body := ts.get(t, "/snippet/edit/1")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	postTests := []struct {
+		name         string
+		urlPath      string
+		title        string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Valid submission",
+			urlPath:      "/snippet/edit/1",
+			title:        "O snail",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+		},
+		{
+			name:     "Empty title",
+			urlPath:  "/snippet/edit/1",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Not owner",
+			urlPath:  "/snippet/edit/3",
+			title:    "O snail",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range postTests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("title", tt.title)
+			form.Add("content", "Climb Mount Fuji")
+			form.Add("visibility", "public")
+			form.Add("expires", "1w")
+			form.Add("csrf_token", validCSRFToken)
+
+			code, header, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+		})
+	}
+}
+
+func TestSnippetFork(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		statusCode, header, _ := ts.get(t, "/snippet/fork/3")
+
+		assert.Equal(t, statusCode, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/user/login")
+	})
+
+	ts.login(t)
+
+	getTests := []struct {
+		name         string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:     "Valid ID",
+			urlPath:  "/snippet/fork/3",
+			wantCode: http.StatusOK,
+			wantBody: "<form action='/snippet/fork/3' method='POST'>",
+		},
+		{
+			name:     "Pre-filled",
+			urlPath:  "/snippet/fork/3",
+			wantCode: http.StatusOK,
+			wantBody: "Over the wintry forest, winds howl in rage</textarea>",
+		},
+		{
+			name:         "Protected",
+			urlPath:      "/snippet/fork/6",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/unlock/6",
+		},
+		{
+			name:     "Limited views",
+			urlPath:  "/snippet/fork/7",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/snippet/fork/2",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range getTests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, header, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+
+	_, _, body := ts.get(t, "/snippet/fork/3")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	postTests := []struct {
+		name         string
+		urlPath      string
+		title        string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Valid submission",
+			urlPath:      "/snippet/fork/3",
+			title:        "Over the wintry forest, again",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+		},
+		{
+			name:     "Empty title",
+			urlPath:  "/snippet/fork/3",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Limited views",
+			urlPath:  "/snippet/fork/7",
+			title:    "One-time secret",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range postTests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("title", tt.title)
+			form.Add("content", "Over the wintry forest, winds howl in rage")
+			form.Add("visibility", "public")
+			form.Add("expires", "1w")
+			form.Add("csrf_token", validCSRFToken)
+
+			code, header, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+		})
+	}
+}
+
+func TestSnippetDelete(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.login(t)
+
+	_, _, body := ts.get(t, "/account/trash")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	t.Run("Trash", func(t *testing.T) {
+		assert.StringContains(t, body, "A leaked credential")
+		assert.StringContains(t, body, "<form action='/snippet/restore/4' method='POST'>")
+	})
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Delete owned",
+			urlPath:      "/snippet/delete/1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/account/trash",
+		},
+		{
+			name:         "Delete expired",
+			urlPath:      "/snippet/delete/9",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/account/trash",
+		},
+		{
+			name:     "Delete not owned",
+			urlPath:  "/snippet/delete/3",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Delete non-existent",
+			urlPath:  "/snippet/delete/2",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:         "Restore owned",
+			urlPath:      "/snippet/restore/4",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/4",
+		},
+		{
+			name:     "Restore not in trash",
+			urlPath:  "/snippet/restore/1",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, header, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+		})
+	}
+}
+
+func TestSnippetRevisionView(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Revision list",
+			urlPath:  "/snippet/view/1",
+			wantCode: http.StatusOK,
+			wantBody: "<a href='/snippet/view/1/rev/1'>#1</a>",
+		},
+		{
+			name:     "Valid revision",
+			urlPath:  "/snippet/view/1/rev/1",
+			wantCode: http.StatusOK,
+			wantBody: "An old pond...",
+		},
+		{
+			name:     "Non-existent revision",
+			urlPath:  "/snippet/view/1/rev/2",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Non-existent snippet",
+			urlPath:  "/snippet/view/2/rev/1",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "String revision",
+			urlPath:  "/snippet/view/1/rev/foo",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Limited views",
+			urlPath:  "/snippet/view/7/rev/1",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSnippetDiff(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Default versions",
+			urlPath:  "/snippet/diff/1",
+			wantCode: http.StatusOK,
+			wantBody: "From revision 1 to current version",
+		},
+		{
+			name:     "Unified",
+			urlPath:  "/snippet/diff/1?from=1&to=2",
+			wantCode: http.StatusOK,
+			wantBody: "<td class='code delete' colspan='2'>An old pond...</td>",
+		},
+		{
+			name:     "Side-by-side",
+			urlPath:  "/snippet/diff/1?mode=split",
+			wantCode: http.StatusOK,
+			wantBody: "<td class='code insert'>An old silent pond...</td>",
+		},
+		{
+			name:     "Same version",
+			urlPath:  "/snippet/diff/1?from=2&to=2",
+			wantCode: http.StatusOK,
+			wantBody: "There are no differences between these versions.",
+		},
+		{
+			name:     "Patch",
+			urlPath:  "/snippet/diff/1?format=patch",
+			wantCode: http.StatusOK,
+			wantBody: "--- a/snippet-1.txt\n+++ b/snippet-1.txt\n@@ -1 +1 @@\n-An old pond...\n\\ No newline at end of file\n+An old silent pond...",
+		},
+		{
+			name:     "Non-existent revision",
+			urlPath:  "/snippet/diff/1?from=3",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Invalid revision",
+			urlPath:  "/snippet/diff/1?to=foo",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Non-existent snippet",
+			urlPath:  "/snippet/diff/2",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Limited views",
+			urlPath:  "/snippet/diff/7",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Empty query",
+			urlPath:  "/search",
+			wantCode: http.StatusOK,
+			wantBody: "<form action='/search' method='GET' class='search'>",
+		},
+		{
+			name:     "Matching query",
+			urlPath:  "/search?q=pond",
+			wantCode: http.StatusOK,
+			wantBody: "<strong>An old silent <mark>pond</mark>...</strong>",
+		},
+		{
+			name:     "No matches",
+			urlPath:  "/search?q=frog",
+			wantCode: http.StatusOK,
+			wantBody: "No snippets match your search.",
+		},
+		{
+			name:     "Invalid page",
+			urlPath:  "/search?q=pond&page=foo",
+			wantCode: http.StatusOK,
+			wantBody: "Found 1 matching snippets",
+		},
+		{
+			name:     "Filters",
+			urlPath:  "/search?q=pond+-frog+lang%3Atext",
+			wantCode: http.StatusOK,
+			wantBody: "<strong>An old silent <mark>pond</mark>...</strong>",
+		},
+		{
+			name:     "Unterminated quote",
+			urlPath:  "/search?q=%22old+pond",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "Invalid search query: unterminated quote",
+		},
+		{
+			name:     "Invalid date",
+			urlPath:  "/search?q=before%3Ayesterday",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "Invalid search query: invalid before date",
+		},
+		{
+			name:     "Tag filter",
+			urlPath:  "/search?q=tag%3Ahaiku",
+			wantCode: http.StatusOK,
+			wantBody: "Found 1 matching snippets",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
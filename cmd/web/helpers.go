@@ -8,16 +8,25 @@ import (
 	"github.com/justinas/nosurf"
 	"net/http"
 	"runtime/debug"
+	"strconv"
+	"strings"
 	"time"
 )
 
 func (app *application) newTemplateData(req *http.Request) *templateData {
-	return &templateData{
+	data := &templateData{
 		CurrentYear:     time.Now().Year(),
 		Flash:           app.sessionManager.PopString(req.Context(), "flash"),
 		IsAuthenticated: app.isAuthenticated(req),
 		CSRFToken:       nosurf.Token(req),
+		ExpiryOptions:   app.expiry.Options,
 	}
+
+	if data.IsAuthenticated {
+		data.AuthenticatedUserID = app.sessionManager.GetInt(req.Context(), "authenticatedUserID")
+	}
+
+	return data
 }
 
 // The clientError helper sends a specific status code and corresponding description
@@ -67,6 +76,85 @@ func (app *application) render(writer http.ResponseWriter, status int, page stri
 	buf.WriteTo(writer)
 }
 
+// negotiate picks the media type of the response among offered ones, the first
+// being the default, according to the Accept header of req. Quality values
+// and wildcards are honoured, the most specific range matching an offered type
+// deciding its quality; ties go to the type offered first. The default is also
+// picked when none of the offered types is acceptable. As the response depends
+// on the header, it's added to Vary.
+func negotiate(writer http.ResponseWriter, req *http.Request, offered ...string) string {
+	writer.Header().Add("Vary", "Accept")
+
+	accept := req.Header.Get("Accept")
+	if accept == "" {
+		return offered[0]
+	}
+
+	best, bestQuality := offered[0], 0.0
+	for _, mediaType := range offered {
+		quality, specificity := 0.0, -1
+
+		for _, mediaRange := range strings.Split(accept, ",") {
+			rangeType, q, ok := parseMediaRange(mediaRange)
+			if !ok {
+				continue
+			}
+
+			s := matchMediaRange(rangeType, mediaType)
+			if s > specificity {
+				quality, specificity = q, s
+			}
+		}
+
+		if quality > bestQuality {
+			best, bestQuality = mediaType, quality
+		}
+	}
+
+	return best
+}
+
+// parseMediaRange parses a single media range of an Accept header, returning
+// its lowercased type and quality
+func parseMediaRange(mediaRange string) (string, float64, bool) {
+	rangeType, params, _ := strings.Cut(mediaRange, ";")
+	rangeType = strings.ToLower(strings.TrimSpace(rangeType))
+	if !strings.Contains(rangeType, "/") {
+		return "", 0, false
+	}
+
+	quality := 1.0
+	for _, param := range strings.Split(params, ";") {
+		key, value, _ := strings.Cut(param, "=")
+		if strings.TrimSpace(key) != "q" {
+			continue
+		}
+
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || q < 0 || q > 1 {
+			return "", 0, false
+		}
+		quality = q
+	}
+
+	return rangeType, quality, true
+}
+
+// matchMediaRange returns how specifically rangeType matches mediaType: 2 for
+// an exact match, 1 for type/*, 0 for */* and -1 when it doesn't match
+func matchMediaRange(rangeType, mediaType string) int {
+	switch {
+	case rangeType == mediaType:
+		return 2
+	case strings.HasSuffix(rangeType, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(rangeType, "*")):
+		return 1
+	case rangeType == "*/*":
+		return 0
+	default:
+		return -1
+	}
+}
+
 // decodePostForm helper method. The second parameter here, dst,
 // is the target destination that we want to decode the form data into.
 func (app *application) decodePostForm(req *http.Request, dst any) error {
@@ -96,3 +184,20 @@ func (app *application) isAuthenticated(r *http.Request) bool {
 	}
 	return isAuthenticated
 }
+
+// cursorParam reads a pagination cursor, i.e. a snippet id, from query
+// parameter name. Zero is returned when the parameter is missing, and false
+// when it isn't a valid id.
+func cursorParam(req *http.Request, name string) (int, bool) {
+	param := req.URL.Query().Get(name)
+	if param == "" {
+		return 0, true
+	}
+
+	id, err := strconv.Atoi(param)
+	if err != nil || id < 1 {
+		return 0, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"snippetbox/internal/assert"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	offered := []string{"text/html", "application/json", "text/plain"}
+
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{
+			name:   "No header",
+			accept: "",
+			want:   "text/html",
+		},
+		{
+			name:   "Exact",
+			accept: "application/json",
+			want:   "application/json",
+		},
+		{
+			name:   "Any",
+			accept: "*/*",
+			want:   "text/html",
+		},
+		{
+			name:   "Subtype wildcard",
+			accept: "application/*",
+			want:   "application/json",
+		},
+		{
+			name:   "Quality",
+			accept: "text/html;q=0.8, text/plain",
+			want:   "text/plain",
+		},
+		{
+			name:   "Tie",
+			accept: "text/plain, application/json",
+			want:   "application/json",
+		},
+		{
+			name:   "Most specific range",
+			accept: "text/*;q=0.9, text/html;q=0.1",
+			want:   "text/plain",
+		},
+		{
+			name:   "Excluded",
+			accept: "*/*, text/html;q=0",
+			want:   "application/json",
+		},
+		{
+			name:   "Case insensitive",
+			accept: "Application/JSON",
+			want:   "application/json",
+		},
+		{
+			name:   "Invalid quality",
+			accept: "application/json;q=2, text/plain",
+			want:   "text/plain",
+		},
+		{
+			name:   "Unacceptable",
+			accept: "image/png",
+			want:   "text/html",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+
+			assert.Equal(t, negotiate(rr, req, offered...), tt.want)
+			assert.Equal(t, rr.Header().Get("Vary"), "Accept")
+		})
+	}
+}
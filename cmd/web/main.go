@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"database/sql"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/alexedwards/scs/mysqlstore"
@@ -12,19 +14,32 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"snippetbox/internal/models"
+	"sync"
+	"syscall"
 	"time"
 )
 
+// shutdownTimeout is how long in-flight requests are given to complete on
+// shutdown
+const shutdownTimeout = 30 * time.Second
+
 type application struct {
 	infoLogger     *log.Logger
 	errorLogger    *log.Logger
 	debugMode      bool
+	pageSize       int
+	expiry         *expiryConfig
 	snippets       models.SnippetModelInterface
 	users          models.UserModelInterface
+	sessions       models.SessionModelInterface
+	tokens         models.TokenModelInterface
+	comments       models.CommentModelInterface
 	templates      TemplateCache
 	formDecoder    *form.Decoder
 	sessionManager *scs.SessionManager
+	unlockLimiter  *attemptLimiter
 }
 
 func openDb(dsn string) (*sql.DB, error) {
@@ -44,11 +59,36 @@ func main() {
 	serverPort := flag.Int("port", 4000, "HTTP network port")
 	dsn := flag.String("dsn", "web:password@/snippetbox?parseTime=true", "MySQL data source name")
 	debug := flag.Bool("debug", false, "Debug mode")
+	pageSize := flag.Int("page-size", 10, "Number of snippets per page when browsing snippets")
+	expiryOptions := flag.String("expiry-options", defaultExpiryOptions, "Comma separated expiry options offered when creating snippets, the first one being the default, e.g. 10m, 1h, 1d, 1w, 1M, 1y or never")
+	maxExpiry := flag.String("max-expiry", defaultMaxExpiry, "Furthest expiry of snippets, also limiting custom expiration dates, e.g. 1y or never")
+	trashRetention := flag.Duration("trash-retention", 30*24*time.Hour, "How long deleted snippets are kept in trash before being purged")
+	expiredRetention := flag.Duration("expired-retention", 7*24*time.Hour, "How long expired snippets are kept before being purged")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [reap]\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Runs the web server, or purges expired snippets, trash and sessions once with reap.")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
 	infoLogger := log.New(os.Stdout, "INFO\t", log.LstdFlags)
 	errorLogger := log.New(os.Stderr, "ERROR\t", log.LstdFlags|log.Lshortfile)
 
+	command := flag.Arg(0)
+	if flag.NArg() > 1 || command != "" && command != "reap" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *pageSize < 1 {
+		errorLogger.Fatal("page size must be positive")
+	}
+
+	expiry, err := parseExpiryConfig(*expiryOptions, *maxExpiry)
+	if err != nil {
+		errorLogger.Fatal(err)
+	}
+
 	db, err := openDb(*dsn)
 	if err != nil {
 		errorLogger.Fatal(err)
@@ -64,17 +104,45 @@ func main() {
 		infoLogger:     infoLogger,
 		errorLogger:    errorLogger,
 		debugMode:      *debug,
+		pageSize:       *pageSize,
+		expiry:         expiry,
 		snippets:       &models.SnippetModel{DB: db},
 		users:          &models.UserModel{DB: db},
+		sessions:       &models.SessionModel{DB: db},
+		tokens:         &models.TokenModel{DB: db},
+		comments:       &models.CommentModel{DB: db},
 		templates:      templateCache,
 		formDecoder:    form.NewDecoder(),
 		sessionManager: scs.New(),
+		unlockLimiter:  newAttemptLimiter(unlockMaxFailures, unlockWindow),
 	}
 
-	app.sessionManager.Store = mysqlstore.New(db)
+	policy := reapPolicy{TrashRetention: *trashRetention, ExpiredRetention: *expiredRetention}
+
+	if command == "reap" {
+		res, err := app.reapOnce(context.Background(), policy)
+		app.logReaped(res)
+		if err != nil {
+			app.errorLogger.Fatal(err)
+		}
+		return
+	}
+
+	// Stale sessions are removed by the reaper, which stops cleanly on shutdown
+	app.sessionManager.Store = mysqlstore.NewWithCleanupInterval(db, 0)
 	app.sessionManager.Lifetime = 12 * time.Hour
 	app.sessionManager.Cookie.Secure = true
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		app.reap(ctx, reapInterval, policy)
+	}()
+
 	srv := &http.Server{
 		Addr:     fmt.Sprintf("%s:%d", *serverAddress, *serverPort),
 		Handler:  app.routes(),
@@ -87,7 +155,28 @@ func main() {
 		WriteTimeout: 10 * time.Second,
 	}
 
+	shutdownErr := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		app.infoLogger.Print("Shutting down server")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		shutdownErr <- srv.Shutdown(shutdownCtx)
+	}()
+
 	app.infoLogger.Printf("Starting server on %s:%d", *serverAddress, *serverPort)
 	err = srv.ListenAndServeTLS("./tls/cert.pem", "./tls/key.pem")
-	app.errorLogger.Fatal(err)
+	if !errors.Is(err, http.ErrServerClosed) {
+		app.errorLogger.Fatal(err)
+	}
+
+	err = <-shutdownErr
+	if err != nil {
+		app.errorLogger.Print(err)
+	}
+
+	workers.Wait()
+	app.infoLogger.Print("Stopped server")
 }
@@ -2,9 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/justinas/alice"
 	"github.com/justinas/nosurf"
 	"net/http"
+	"snippetbox/internal/models"
+	"strings"
 )
 
 func secureHeaders(next http.Handler) http.Handler {
@@ -89,3 +93,57 @@ func CSRFToken(next http.Handler) http.Handler {
 
 	return csrfHandler
 }
+
+// authenticateToken authenticates API requests carrying a personal API token in
+// an "Authorization: Bearer" header, which are rejected when the token is
+// invalid. Requests without a token are passed on as anonymous.
+func (app *application) authenticateToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Add("Vary", "Authorization")
+
+		header := request.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		scheme, plaintext, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			app.apiUnauthorized(writer, "Authorization header must be a Bearer token")
+			return
+		}
+
+		token, err := app.tokens.Authenticate(strings.TrimSpace(plaintext))
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidCredentials) {
+				app.apiUnauthorized(writer, "Invalid or revoked token")
+				return
+			}
+			app.apiServerError(writer, err)
+			return
+		}
+
+		ctx := context.WithValue(request.Context(), apiTokenContextKey, token)
+		next.ServeHTTP(writer, request.WithContext(ctx))
+	})
+}
+
+// apiRequireScope rejects API requests not authenticated with a token allowing
+// scope
+func (app *application) apiRequireScope(scope models.TokenScope) alice.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			token := apiToken(request)
+			if token == nil {
+				app.apiUnauthorized(writer, "Authentication required")
+				return
+			}
+			if !token.Scope.Allows(scope) {
+				app.apiError(writer, http.StatusForbidden, fmt.Sprintf("The token lacks the %s scope", scope))
+				return
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Wrong passwords of a single snippet are limited to unlockMaxFailures per
+// unlockWindow, whoever enters them
+const (
+	unlockMaxFailures = 5
+	unlockWindow      = 15 * time.Minute
+)
+
+// attemptLimiter limits the number of failed attempts per key, e.g. of
+// unlocking a single snippet, within a fixed time window. Attempts are counted
+// as failed up front, so that concurrent attempts can't get past the limit
+// while they're being checked, and successful ones are released afterwards.
+type attemptLimiter struct {
+	mu       sync.Mutex
+	max      int
+	window   time.Duration
+	attempts map[int]*attemptWindow
+	// now is replaced in tests
+	now func() time.Time
+}
+
+type attemptWindow struct {
+	start    time.Time
+	failures int
+}
+
+func newAttemptLimiter(max int, window time.Duration) *attemptLimiter {
+	return &attemptLimiter{
+		max:      max,
+		window:   window,
+		attempts: map[int]*attemptWindow{},
+		now:      time.Now,
+	}
+}
+
+// Allow reports whether another attempt for key may be made and if so, records
+// it as failed until it's released
+func (l *attemptLimiter) Allow(key int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// windows that are over are dropped, so that the map doesn't keep growing
+	for k, w := range l.attempts {
+		if l.expired(w) {
+			delete(l.attempts, k)
+		}
+	}
+
+	w, ok := l.attempts[key]
+	if !ok {
+		w = &attemptWindow{start: l.now()}
+		l.attempts[key] = w
+	}
+	if w.failures >= l.max {
+		return false
+	}
+	w.failures++
+
+	return true
+}
+
+// Release takes back an attempt for key allowed by Allow that didn't fail
+func (l *attemptLimiter) Release(key int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.attempts[key]
+	if ok && w.failures > 0 {
+		w.failures--
+	}
+}
+
+func (l *attemptLimiter) expired(w *attemptWindow) bool {
+	return l.now().Sub(w.start) >= l.window
+}
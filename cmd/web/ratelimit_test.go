@@ -0,0 +1,54 @@
+package main
+
+import (
+	"snippetbox/internal/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAttemptLimiter(t *testing.T) {
+	now := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	l := newAttemptLimiter(2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	assert.Equal(t, l.Allow(1), true)
+	assert.Equal(t, l.Allow(1), true)
+	assert.Equal(t, l.Allow(1), false)
+
+	// released attempts don't count
+	l.Release(1)
+	assert.Equal(t, l.Allow(1), true)
+	assert.Equal(t, l.Allow(1), false)
+
+	// other keys are limited separately
+	assert.Equal(t, l.Allow(2), true)
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, l.Allow(1), true)
+	assert.Equal(t, len(l.attempts), 1)
+}
+
+func TestAttemptLimiterConcurrent(t *testing.T) {
+	l := newAttemptLimiter(unlockMaxFailures, unlockWindow)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+
+	for i := 0; i < 4*unlockMaxFailures; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if l.Allow(1) {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, allowed, unlockMaxFailures)
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/justinas/alice"
 	"net/http"
+	"snippetbox/internal/models"
 	"snippetbox/ui"
 )
 
@@ -23,7 +24,15 @@ func (app *application) routes() http.Handler {
 
 	router.Handler(http.MethodGet, "/about", dynamic.ThenFunc(app.about))
 	router.Handler(http.MethodGet, "/", dynamic.ThenFunc(app.home))
+	router.Handler(http.MethodGet, "/tag/:name", dynamic.ThenFunc(app.tagView))
 	router.Handler(http.MethodGet, "/snippet/view/:id", dynamic.ThenFunc(app.snippetView))
+	router.Handler(http.MethodGet, "/snippet/view/:id/rev/:n", dynamic.ThenFunc(app.snippetRevisionView))
+	router.Handler(http.MethodGet, "/snippet/raw/:id", dynamic.ThenFunc(app.snippetRaw))
+	router.Handler(http.MethodGet, "/snippet/download/:id", dynamic.ThenFunc(app.snippetDownload))
+	router.Handler(http.MethodGet, "/snippet/unlock/:id", dynamic.ThenFunc(app.snippetUnlock))
+	router.Handler(http.MethodPost, "/snippet/unlock/:id", dynamic.ThenFunc(app.snippetUnlockPost))
+	router.Handler(http.MethodGet, "/snippet/diff/:id", dynamic.ThenFunc(app.snippetDiff))
+	router.Handler(http.MethodGet, "/search", dynamic.ThenFunc(app.search))
 	router.Handler(http.MethodGet, "/user/signup", dynamic.ThenFunc(app.userSignup))
 	router.Handler(http.MethodPost, "/user/signup", dynamic.ThenFunc(app.userSignupPost))
 	router.Handler(http.MethodGet, "/user/login", dynamic.ThenFunc(app.userLogin))
@@ -34,10 +43,38 @@ func (app *application) routes() http.Handler {
 	router.Handler(http.MethodGet, "/account/password/update", protected.ThenFunc(app.accountPasswordUpdate))
 	router.Handler(http.MethodPost, "/account/password/update", protected.ThenFunc(app.accountPasswordUpdatePost))
 	router.Handler(http.MethodGet, "/account/view", protected.ThenFunc(app.accountView))
+	router.Handler(http.MethodGet, "/account/trash", protected.ThenFunc(app.accountTrash))
+	router.Handler(http.MethodGet, "/account/starred", protected.ThenFunc(app.accountStarred))
+	router.Handler(http.MethodGet, "/account/tokens", protected.ThenFunc(app.accountTokens))
+	router.Handler(http.MethodPost, "/account/tokens", protected.ThenFunc(app.accountTokensPost))
+	router.Handler(http.MethodPost, "/account/tokens/revoke/:id", protected.ThenFunc(app.accountTokenRevokePost))
 	router.Handler(http.MethodGet, "/snippet/create", protected.ThenFunc(app.snippetCreate))
 	router.Handler(http.MethodPost, "/snippet/create", protected.ThenFunc(app.snippetCreatePost))
+	router.Handler(http.MethodPost, "/snippet/comment/:id", protected.ThenFunc(app.snippetCommentPost))
+	router.Handler(http.MethodGet, "/comment/edit/:id", protected.ThenFunc(app.commentEdit))
+	router.Handler(http.MethodPost, "/comment/edit/:id", protected.ThenFunc(app.commentEditPost))
+	router.Handler(http.MethodPost, "/comment/delete/:id", protected.ThenFunc(app.commentDeletePost))
+	router.Handler(http.MethodPost, "/snippet/star/:id", protected.ThenFunc(app.snippetStarPost))
+	router.Handler(http.MethodPost, "/snippet/unstar/:id", protected.ThenFunc(app.snippetUnstarPost))
+	router.Handler(http.MethodGet, "/snippet/fork/:id", protected.ThenFunc(app.snippetFork))
+	router.Handler(http.MethodPost, "/snippet/fork/:id", protected.ThenFunc(app.snippetForkPost))
+	router.Handler(http.MethodGet, "/snippet/edit/:id", protected.ThenFunc(app.snippetEdit))
+	router.Handler(http.MethodPost, "/snippet/edit/:id", protected.ThenFunc(app.snippetEditPost))
+	router.Handler(http.MethodPost, "/snippet/delete/:id", protected.ThenFunc(app.snippetDeletePost))
+	router.Handler(http.MethodPost, "/snippet/restore/:id", protected.ThenFunc(app.snippetRestorePost))
 	router.Handler(http.MethodPost, "/user/logout", protected.ThenFunc(app.userLogoutPost))
 
+	// The API authenticates every request on its own, so it needs neither
+	// sessions nor CSRF protection
+	api := alice.New(app.authenticateToken)
+	apiWrite := api.Append(app.apiRequireScope(models.TokenScopeWrite))
+
+	router.Handler(http.MethodGet, "/api/v1/snippets", api.ThenFunc(app.apiSnippetList))
+	router.Handler(http.MethodGet, "/api/v1/snippets/:id", api.ThenFunc(app.apiSnippetView))
+	router.Handler(http.MethodPost, "/api/v1/snippets", apiWrite.ThenFunc(app.apiSnippetCreate))
+	router.Handler(http.MethodPut, "/api/v1/snippets/:id", apiWrite.ThenFunc(app.apiSnippetUpdate))
+	router.Handler(http.MethodDelete, "/api/v1/snippets/:id", apiWrite.ThenFunc(app.apiSnippetDelete))
+
 	standard := alice.New(app.recoverPanic, app.logRequest, secureHeaders)
 	return standard.Then(router)
 }
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"snippetbox/internal/models"
+	"snippetbox/internal/validator"
+	"strconv"
+)
+
+// starrableSnippet fetches snippet identified by the id route parameter for
+// starring or unstarring it, without counting a view. On failure the appropriate
+// response is already written and nil is returned.
+func (app *application) starrableSnippet(writer http.ResponseWriter, req *http.Request) *models.Snippet {
+	params := httprouter.ParamsFromContext(req.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(writer)
+		return nil
+	}
+
+	userID := app.sessionManager.GetInt(req.Context(), "authenticatedUserID")
+
+	return app.peekSnippet(writer, req, id, userID)
+}
+
+func (app *application) snippetStarPost(writer http.ResponseWriter, req *http.Request) {
+	snippet := app.starrableSnippet(writer, req)
+	if snippet == nil {
+		return
+	}
+
+	userID := app.sessionManager.GetInt(req.Context(), "authenticatedUserID")
+
+	err := app.snippets.Star(snippet.ID, userID)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	http.Redirect(writer, req, fmt.Sprintf("/snippet/view/%d", snippet.ID), http.StatusSeeOther)
+}
+
+func (app *application) snippetUnstarPost(writer http.ResponseWriter, req *http.Request) {
+	snippet := app.starrableSnippet(writer, req)
+	if snippet == nil {
+		return
+	}
+
+	userID := app.sessionManager.GetInt(req.Context(), "authenticatedUserID")
+
+	err := app.snippets.Unstar(snippet.ID, userID)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	http.Redirect(writer, req, fmt.Sprintf("/snippet/view/%d", snippet.ID), http.StatusSeeOther)
+}
+
+// accountStarred lists snippets starred by the current user, most recently
+// starred or most starred first
+func (app *application) accountStarred(writer http.ResponseWriter, req *http.Request) {
+	id := app.sessionManager.GetInt(req.Context(), "authenticatedUserID")
+
+	list := &listing{Sort: req.URL.Query().Get("sort")}
+	if !validator.PermittedValue(list.Sort, models.SortStarred, models.SortStars) {
+		list.Sort = models.SortStarred
+	}
+
+	snippets, err := app.snippets.Starred(id, list.Sort)
+	if err != nil {
+		app.serverError(writer, err)
+		return
+	}
+
+	data := app.newTemplateData(req)
+	data.Snippets = snippets
+	data.Listing = list
+
+	app.render(writer, http.StatusOK, "starred.tmpl.html", data)
+}
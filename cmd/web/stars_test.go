@@ -0,0 +1,170 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"snippetbox/internal/assert"
+	"strings"
+	"testing"
+)
+
+func TestSnippetStars(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Anonymous", func(t *testing.T) {
+		code, _, body := ts.get(t, "/snippet/view/1")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "2 stars")
+		if strings.Contains(body, "<button>Star</button>") {
+			t.Error("got star button for an anonymous user")
+		}
+	})
+
+	ts.login(t)
+
+	tests := []struct {
+		name       string
+		urlPath    string
+		wantAction string
+		wantButton string
+	}{
+		{
+			name:       "Not starred",
+			urlPath:    "/snippet/view/1",
+			wantAction: "action='/snippet/star/1'",
+			wantButton: "<button>Star</button>",
+		},
+		{
+			name:       "Starred",
+			urlPath:    "/snippet/view/3",
+			wantAction: "action='/snippet/unstar/3'",
+			wantButton: "<button>Unstar</button>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, http.StatusOK)
+			assert.StringContains(t, body, tt.wantAction)
+			assert.StringContains(t, body, tt.wantButton)
+		})
+	}
+}
+
+func TestSnippetStarPost(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.login(t)
+
+	_, _, body := ts.get(t, "/snippet/view/1")
+	validCSRFToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Star",
+			urlPath:      "/snippet/star/1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+		},
+		{
+			name:         "Unstar",
+			urlPath:      "/snippet/unstar/3",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/3",
+		},
+		{
+			name:         "Locked snippet",
+			urlPath:      "/snippet/star/6",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/unlock/6",
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/snippet/star/9",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Invalid ID",
+			urlPath:  "/snippet/unstar/foo",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", validCSRFToken)
+
+			code, header, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, header.Get("Location"), tt.wantLocation)
+		})
+	}
+}
+
+func TestAccountStarred(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, header, _ := ts.get(t, "/account/starred")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, header.Get("Location"), "/user/login")
+	})
+
+	ts.login(t)
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantBody string
+	}{
+		{
+			name:     "Default sort",
+			urlPath:  "/account/starred",
+			wantBody: `<a href='/account/starred?sort=starred' class='live'>`,
+		},
+		{
+			name:     "Stars sort",
+			urlPath:  "/account/starred?sort=stars",
+			wantBody: `<a href='/account/starred?sort=stars' class='live'>`,
+		},
+		{
+			name:     "Invalid sort",
+			urlPath:  "/account/starred?sort=foo",
+			wantBody: `<a href='/account/starred?sort=starred' class='live'>`,
+		},
+		{
+			name:     "Listing",
+			urlPath:  "/account/starred",
+			wantBody: "<a href='/snippet/view/3'>Over the wintry forest</a>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, http.StatusOK)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
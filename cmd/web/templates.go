@@ -1,23 +1,208 @@
 package main
 
 import (
+	"fmt"
 	"html/template"
 	"io/fs"
+	"net/url"
 	"path/filepath"
+	"regexp"
+	"snippetbox/internal/diff"
+	"snippetbox/internal/highlight"
 	"snippetbox/internal/models"
 	"snippetbox/ui"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 type templateData struct {
-	CurrentYear     int
-	Snippet         *models.Snippet
-	User            *models.User
-	Snippets        []*models.Snippet
-	Form            any
-	Flash           string
-	IsAuthenticated bool
-	CSRFToken       string
+	CurrentYear int
+	Snippet     *models.Snippet
+	// ForkedFrom is the snippet being forked
+	ForkedFrom *models.Snippet
+	Forks      []*models.Snippet
+	// Starred is set when the authenticated user starred Snippet
+	Starred     bool
+	User        *models.User
+	Snippets    []*models.Snippet
+	SnippetPage *models.SnippetPage
+	Tag         string
+	TagCloud    []cloudTag
+	Revision    *models.Revision
+	Revisions   []*models.Revision
+	// Version is the number of the current version of Snippet
+	Version       int
+	Code          *codeView
+	Comment       *models.Comment
+	Comments      []*models.Comment
+	Diff          *diffView
+	Query         string
+	SearchWords   string
+	SearchResults *models.SearchResults
+	SnippetCounts models.SnippetCounts
+	Listing       *listing
+	ExpiryOptions []expiryOption
+	Tokens        []*models.Token
+	// NewToken is the plaintext of a token just created, shown only once
+	NewToken            string
+	Form                any
+	Flash               string
+	IsAuthenticated     bool
+	AuthenticatedUserID int
+	CSRFToken           string
+}
+
+// listing holds the filtering, sorting and pagination state of a snippet list
+// rendered by a template, so that its links can preserve it.
+type listing struct {
+	Status     string
+	Sort       string
+	Page       int
+	TotalPages int
+}
+
+func (l *listing) HasPrev() bool {
+	return l.Page > 1
+}
+
+func (l *listing) HasNext() bool {
+	return l.Page < l.TotalPages
+}
+
+func (l *listing) PrevPage() int {
+	return l.Page - 1
+}
+
+func (l *listing) NextPage() int {
+	return l.Page + 1
+}
+
+// diffView holds the changes between two versions of a snippet. Versions are
+// numbered like revisions, with the current version numbered Latest.
+type diffView struct {
+	SnippetID int
+	From      int
+	To        int
+	Latest    int
+	Mode      string
+	Hunks     []diff.Hunk
+}
+
+func (d *diffView) VersionName(number int) string {
+	if number == d.Latest {
+		return "current version"
+	}
+	return fmt.Sprintf("revision %d", number)
+}
+
+// codeView is a version of snippet content laid out line by line, with line
+// comments pinned to that version shown below the last line they're about
+type codeView struct {
+	Lines []codeLine
+}
+
+type codeLine struct {
+	highlight.Line
+	// Threads are line comments ending at the line, each followed by its replies
+	Threads [][]*models.Comment
+}
+
+// highlightCode lays out content highlighted as language for the code partial,
+// without any line comments
+func highlightCode(content, language string) (*codeView, error) {
+	return newCodeView(content, language, 0, nil)
+}
+
+// newCodeView highlights content of given version of a snippet in language and
+// pins the line comments from comments, a thread as returned by Thread, to it
+func newCodeView(content, language string, version int, comments []*models.Comment) (*codeView, error) {
+	lines, err := highlight.Lines(content, language)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &codeView{Lines: make([]codeLine, len(lines))}
+	for i, line := range lines {
+		view.Lines[i].Line = line
+	}
+
+	for i, comment := range comments {
+		if comment.Depth > 0 || comment.Lines.Version != version || comment.Lines.End > len(lines) || comment.Lines.Start < 1 {
+			continue
+		}
+
+		thread := []*models.Comment{comment}
+		for _, reply := range comments[i+1:] {
+			if reply.Depth == 0 {
+				break
+			}
+			thread = append(thread, reply)
+		}
+
+		last := &view.Lines[comment.Lines.End-1]
+		last.Threads = append(last.Threads, thread)
+	}
+
+	return view, nil
+}
+
+// tagCloudLevels is the number of sizes tags of a tag cloud are shown in
+const tagCloudLevels = 5
+
+// cloudTag is a tag of a tag cloud. Level grows from 1 to tagCloudLevels with
+// the number of snippets tagged, it selects the size of the tag.
+type cloudTag struct {
+	Name  string
+	Count int
+	Level int
+}
+
+// newTagCloud scales counts of tags to levels, linearly between the least and
+// the most used tag, and sorts the tags by name
+func newTagCloud(tags []*models.Tag) []cloudTag {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	least, most := tags[0].Count, tags[0].Count
+	for _, tag := range tags {
+		if tag.Count < least {
+			least = tag.Count
+		}
+		if tag.Count > most {
+			most = tag.Count
+		}
+	}
+
+	cloud := make([]cloudTag, 0, len(tags))
+	for _, tag := range tags {
+		level := 1
+		if most > least {
+			level += (tag.Count - least) * (tagCloudLevels - 1) / (most - least)
+		}
+		cloud = append(cloud, cloudTag{Name: tag.Name, Count: tag.Count, Level: level})
+	}
+
+	sort.Slice(cloud, func(i, j int) bool {
+		return cloud[i].Name < cloud[j].Name
+	})
+
+	return cloud
+}
+
+// maxCommentIndent is the deepest level replies are indented to, deeper ones
+// are shown at that level
+const maxCommentIndent = 5
+
+// commentIndent returns the indentation level of comments at given depth
+func commentIndent(depth int) int {
+	if depth > maxCommentIndent {
+		return maxCommentIndent
+	}
+	return depth
 }
 
 func humanDate(t time.Time) string {
@@ -27,8 +212,94 @@ func humanDate(t time.Time) string {
 	return t.UTC().Format("02 Jan 2006 at 15:04")
 }
 
+// excerptLength is the approximate length in bytes of the content fragment
+// shown by excerpt
+const excerptLength = 200
+
+// termsRegexp matches any of the words of a search query, case insensitive.
+// It returns nil for a query with no words.
+func termsRegexp(query string) *regexp.Regexp {
+	var terms []string
+	for _, term := range strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		terms = append(terms, regexp.QuoteMeta(term))
+	}
+
+	if len(terms) == 0 {
+		return nil
+	}
+
+	return regexp.MustCompile(`(?i)` + strings.Join(terms, "|"))
+}
+
+// markTerms escapes text and wraps every occurrence of words of query in it
+// with a <mark> element
+func markTerms(text, query string) template.HTML {
+	rx := termsRegexp(query)
+	if rx == nil {
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+
+	var b strings.Builder
+
+	last := 0
+	for _, loc := range rx.FindAllStringIndex(text, -1) {
+		b.WriteString(template.HTMLEscapeString(text[last:loc[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(template.HTMLEscapeString(text[loc[0]:loc[1]]))
+		b.WriteString("</mark>")
+		last = loc[1]
+	}
+	b.WriteString(template.HTMLEscapeString(text[last:]))
+
+	return template.HTML(b.String())
+}
+
+// excerpt returns a fragment of content around the first occurrence of any
+// word of query, with the words marked as in markTerms
+func excerpt(content, query string) template.HTML {
+	start := 0
+	if rx := termsRegexp(query); rx != nil {
+		if loc := rx.FindStringIndex(content); loc != nil {
+			start = loc[0] - excerptLength/4
+		}
+	}
+	if start < 0 {
+		start = 0
+	}
+	end := start + excerptLength
+	if end > len(content) {
+		end = len(content)
+	}
+
+	for start > 0 && !utf8.RuneStart(content[start]) {
+		start--
+	}
+	for end < len(content) && !utf8.RuneStart(content[end]) {
+		end++
+	}
+
+	fragment := markTerms(content[start:end], query)
+	if start > 0 {
+		fragment = "…" + fragment
+	}
+	if end < len(content) {
+		fragment += "…"
+	}
+
+	return fragment
+}
+
 var functions = template.FuncMap{
-	"humanDate": humanDate,
+	"humanDate":     humanDate,
+	"highlight":     highlightCode,
+	"languages":     func() []highlight.Language { return highlight.Languages },
+	"languageLabel": highlight.Label,
+	"markTerms":     markTerms,
+	"excerpt":       excerpt,
+	"pathEscape":    url.PathEscape,
+	"commentIndent": commentIndent,
 }
 
 type TemplateCache map[string]*template.Template
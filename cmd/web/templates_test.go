@@ -1,7 +1,11 @@
 package main
 
 import (
+	"fmt"
+	"html/template"
 	"snippetbox/internal/assert"
+	"snippetbox/internal/models"
+	"strings"
 	"testing"
 	"time"
 )
@@ -38,3 +42,184 @@ func TestHumanDate(t *testing.T) {
 	}
 
 }
+
+func TestMarkTerms(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		want  template.HTML
+	}{
+		{
+			name:  "Single term",
+			text:  "An old silent pond",
+			query: "pond",
+			want:  "An old silent <mark>pond</mark>",
+		},
+		{
+			name:  "Case insensitive",
+			text:  "An old silent Pond",
+			query: "OLD pond",
+			want:  "An <mark>old</mark> silent <mark>Pond</mark>",
+		},
+		{
+			name:  "Escaped",
+			text:  "<b>pond</b>",
+			query: "pond",
+			want:  "&lt;b&gt;<mark>pond</mark>&lt;/b&gt;",
+		},
+		{
+			name:  "Regexp characters",
+			text:  "a.b a+b",
+			query: "a+b",
+			want:  "<mark>a</mark>.<mark>b</mark> <mark>a</mark>+<mark>b</mark>",
+		},
+		{
+			name:  "No terms",
+			text:  "An old silent pond",
+			query: "!!",
+			want:  "An old silent pond",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, markTerms(tt.text, tt.query), tt.want)
+		})
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	long := strings.Repeat("x", excerptLength)
+
+	tests := []struct {
+		name    string
+		content string
+		query   string
+		want    template.HTML
+	}{
+		{
+			name:    "Short content",
+			content: "An old silent pond",
+			query:   "pond",
+			want:    "An old silent <mark>pond</mark>",
+		},
+		{
+			name:    "Match at the end",
+			content: long + " pond",
+			query:   "pond",
+			want:    template.HTML("…" + long[:excerptLength/4-1] + " <mark>pond</mark>"),
+		},
+		{
+			name:    "No match",
+			content: long + " pond",
+			query:   "frog",
+			want:    template.HTML(long + "…"),
+		},
+		{
+			name:    "Multibyte boundary",
+			content: strings.Repeat("ż", excerptLength),
+			query:   "",
+			want:    template.HTML(strings.Repeat("ż", excerptLength/2) + "…"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, excerpt(tt.content, tt.query), tt.want)
+		})
+	}
+}
+
+func TestNewTagCloud(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []*models.Tag
+		want string
+	}{
+		{
+			name: "Empty",
+			want: "",
+		},
+		{
+			name: "Single",
+			tags: []*models.Tag{{Name: "go", Count: 3}},
+			want: "go:1",
+		},
+		{
+			name: "Scaled",
+			tags: []*models.Tag{
+				{Name: "sql", Count: 9},
+				{Name: "go", Count: 5},
+				{Name: "k8s", Count: 1},
+			},
+			want: "go:3 k8s:1 sql:5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, tag := range newTagCloud(tt.tags) {
+				got = append(got, fmt.Sprintf("%s:%d", tag.Name, tag.Level))
+			}
+
+			assert.Equal(t, strings.Join(got, " "), tt.want)
+		})
+	}
+}
+
+func TestNewCodeView(t *testing.T) {
+	comments := []*models.Comment{
+		{ID: 1, Content: "General"},
+		{ID: 2, Lines: models.LineRange{Version: 2, Start: 1, End: 2}, Content: "Range"},
+		{ID: 3, ParentID: 2, Depth: 1, Content: "Reply"},
+		{ID: 4, Lines: models.LineRange{Version: 1, Start: 1, End: 1}, Content: "Outdated"},
+		{ID: 5, Lines: models.LineRange{Version: 2, Start: 3, End: 3}, Content: "Last line"},
+		{ID: 6, Lines: models.LineRange{Version: 2, Start: 4, End: 4}, Content: "Past the end"},
+	}
+
+	view, err := newCodeView("a\nb\nc\n", "", 2, comments)
+	assert.NilError(t, err)
+
+	assert.Equal(t, len(view.Lines), 3)
+
+	var threads [][]int
+	for _, line := range view.Lines {
+		var ids []int
+		for _, thread := range line.Threads {
+			for _, comment := range thread {
+				ids = append(ids, comment.ID)
+			}
+		}
+		threads = append(threads, ids)
+	}
+
+	assert.Equal(t, fmt.Sprint(threads), "[[] [2 3] [5]]")
+}
+
+func TestSnippetPartial(t *testing.T) {
+	cache, err := newTemplateCache()
+	assert.NilError(t, err)
+
+	snippet := &models.Snippet{
+		ID:       1,
+		Author:   "test",
+		Title:    "An old silent pond...",
+		Content:  "package main\n",
+		Language: "go",
+		Comments: 4,
+		Stars:    2,
+	}
+
+	var buf strings.Builder
+	err = cache["home.tmpl.html"].ExecuteTemplate(&buf, "snippet", snippet)
+	assert.NilError(t, err)
+
+	body := buf.String()
+	assert.StringContains(t, body, "<em>by test</em>")
+	assert.StringContains(t, body, "4 comments")
+	assert.StringContains(t, body, "2 stars")
+	assert.StringContains(t, body, "<span class='line' id='L1'>")
+	assert.StringContains(t, body, `<span class="kn">package</span>`)
+}
@@ -13,6 +13,7 @@ import (
 	"net/url"
 	"regexp"
 	"snippetbox/internal/models/mocks"
+	"strings"
 	"testing"
 	"time"
 )
@@ -23,6 +24,11 @@ func newTestApplication(t *testing.T) *application {
 		t.Fatal(err)
 	}
 
+	expiry, err := parseExpiryConfig(defaultExpiryOptions, defaultMaxExpiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	formDecoder := form.NewDecoder()
 
 	sessionManager := scs.New()
@@ -32,11 +38,17 @@ func newTestApplication(t *testing.T) *application {
 	return &application{
 		errorLogger:    log.New(io.Discard, "", 0),
 		infoLogger:     log.New(io.Discard, "", 0),
+		pageSize:       10,
+		expiry:         expiry,
 		snippets:       &mocks.SnippetModel{},
 		users:          &mocks.UserModel{},
+		sessions:       &mocks.SessionModel{},
+		tokens:         &mocks.TokenModel{},
+		comments:       &mocks.CommentModel{},
 		templates:      templateCache,
 		formDecoder:    formDecoder,
 		sessionManager: sessionManager,
+		unlockLimiter:  newAttemptLimiter(unlockMaxFailures, unlockWindow),
 	}
 }
 
@@ -76,6 +88,27 @@ func (ts *testServer) get(t *testing.T, urlPath string) (int, http.Header, strin
 	return rs.StatusCode, rs.Header, string(bytes.TrimSpace(body))
 }
 
+// getAccept sends a GET request asking for a response in given media type
+func (ts *testServer) getAccept(t *testing.T, urlPath, accept string) (int, http.Header, string) {
+	req, err := http.NewRequest(http.MethodGet, ts.URL+urlPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", accept)
+
+	rs, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Body.Close()
+	body, err := io.ReadAll(rs.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rs.StatusCode, rs.Header, string(bytes.TrimSpace(body))
+}
+
 func (ts *testServer) postForm(t *testing.T, urlPath string, form url.Values) (int, http.Header, string) {
 	rs, err := ts.Client().PostForm(ts.URL+urlPath, form)
 	if err != nil {
@@ -90,6 +123,33 @@ func (ts *testServer) postForm(t *testing.T, urlPath string, form url.Values) (i
 	return rs.StatusCode, rs.Header, string(bytes.TrimSpace(body))
 }
 
+// apiRequest sends an API request with a JSON body, unless it's empty,
+// authenticated with token, unless it's empty
+func (ts *testServer) apiRequest(t *testing.T, method, urlPath, body, token string) (int, http.Header, string) {
+	req, err := http.NewRequest(method, ts.URL+urlPath, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rs, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Body.Close()
+	respBody, err := io.ReadAll(rs.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rs.StatusCode, rs.Header, string(bytes.TrimSpace(respBody))
+}
+
 var csrfTokenRX = regexp.MustCompile(`<input type='hidden' name='csrf_token' value='(.+)'>`)
 
 func extractCSRFToken(t *testing.T, body string) string {
@@ -100,3 +160,17 @@ func extractCSRFToken(t *testing.T, body string) string {
 
 	return html.UnescapeString(matches[1])
 }
+
+// login signs the test server's client in as the mock user, so that following
+// requests share an authenticated session through the cookie jar.
+func (ts *testServer) login(t *testing.T) {
+	_, _, body := ts.get(t, "/user/login")
+	csrfToken := extractCSRFToken(t, body)
+
+	form := url.Values{}
+	form.Add("email", "test@email.com")
+	form.Add("password", "password")
+	form.Add("csrf_token", csrfToken)
+
+	ts.postForm(t, "/user/login", form)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// reapInterval is how often reap looks for rows to remove
+	reapInterval = time.Hour
+	// reapBatchSize is the number of rows removed by a single query, which keeps
+	// the tables from being locked for long
+	reapBatchSize = 500
+)
+
+// reapPolicy tells how long removable snippets are kept around
+type reapPolicy struct {
+	// TrashRetention is how long deleted snippets stay in trash
+	TrashRetention time.Duration
+	// ExpiredRetention is how long expired snippets are kept, so that their
+	// owners can still find them in their account
+	ExpiredRetention time.Duration
+}
+
+// reapResult holds the number of rows removed by reapOnce
+type reapResult struct {
+	Expired  int
+	Trashed  int
+	Sessions int
+}
+
+// reap periodically removes expired snippets, snippets that have been sitting
+// in trash and stale sessions until ctx is cancelled. It's meant to be run in
+// its own goroutine for the whole lifetime of the application.
+func (app *application) reap(ctx context.Context, interval time.Duration, policy reapPolicy) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			res, err := app.reapOnce(ctx, policy)
+			if err != nil {
+				app.errorLogger.Printf("reaping: %s", err)
+			}
+			app.logReaped(res)
+		}
+	}
+}
+
+// reapOnce removes everything reap would remove in a single pass, stopping
+// between batches when ctx is cancelled. The rows removed before an error are
+// counted in the result too.
+func (app *application) reapOnce(ctx context.Context, policy reapPolicy) (reapResult, error) {
+	var res reapResult
+	var err error
+
+	res.Expired, err = batched(ctx, func() (int, error) {
+		return app.snippets.PurgeExpired(policy.ExpiredRetention, reapBatchSize)
+	})
+	if err != nil {
+		return res, err
+	}
+
+	res.Trashed, err = app.snippets.PurgeTrash(policy.TrashRetention)
+	if err != nil {
+		return res, err
+	}
+
+	res.Sessions, err = batched(ctx, func() (int, error) {
+		return app.sessions.PurgeExpired(reapBatchSize)
+	})
+
+	return res, err
+}
+
+// batched calls purge until it removes less than reapBatchSize rows or ctx is
+// cancelled and returns the total number of rows removed
+func batched(ctx context.Context, purge func() (int, error)) (int, error) {
+	total := 0
+
+	for ctx.Err() == nil {
+		n, err := purge()
+		total += n
+		if err != nil || n < reapBatchSize {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+func (app *application) logReaped(res reapResult) {
+	if res != (reapResult{}) {
+		app.infoLogger.Printf("Reaped %d expired snippets, %d snippets from trash and %d sessions",
+			res.Expired, res.Trashed, res.Sessions)
+	}
+}
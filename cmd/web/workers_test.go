@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"snippetbox/internal/assert"
+	"testing"
+	"time"
+)
+
+func TestBatched(t *testing.T) {
+	errPurge := errors.New("purge failed")
+
+	tests := []struct {
+		name      string
+		batches   []int
+		err       error
+		cancelled bool
+		wantTotal int
+		wantCalls int
+	}{
+		{
+			name:      "Nothing to purge",
+			batches:   []int{0},
+			wantTotal: 0,
+			wantCalls: 1,
+		},
+		{
+			name:      "Until short batch",
+			batches:   []int{reapBatchSize, reapBatchSize, 3},
+			wantTotal: 2*reapBatchSize + 3,
+			wantCalls: 3,
+		},
+		{
+			name:      "Error",
+			batches:   []int{reapBatchSize, 2},
+			err:       errPurge,
+			wantTotal: reapBatchSize + 2,
+			wantCalls: 2,
+		},
+		{
+			name:      "Cancelled",
+			batches:   []int{reapBatchSize},
+			cancelled: true,
+			wantTotal: 0,
+			wantCalls: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			calls := 0
+			total, err := batched(ctx, func() (int, error) {
+				n := tt.batches[calls]
+				calls++
+				if calls == len(tt.batches) {
+					return n, tt.err
+				}
+				return n, nil
+			})
+
+			assert.Equal(t, errors.Is(err, tt.err), true)
+			assert.Equal(t, total, tt.wantTotal)
+			assert.Equal(t, calls, tt.wantCalls)
+		})
+	}
+}
+
+func TestReapStops(t *testing.T) {
+	app := newTestApplication(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		app.reap(ctx, time.Millisecond, reapPolicy{})
+		close(done)
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("reap didn't stop after cancellation")
+	}
+}
@@ -3,12 +3,15 @@ module snippetbox
 go 1.19
 
 require (
-	github.com/alexedwards/scs/mysqlstore v0.0.0-20230217120314-6b1bedc0f08c // indirect
-	github.com/alexedwards/scs/v2 v2.5.0 // indirect
-	github.com/go-playground/form/v4 v4.2.0 // indirect
-	github.com/go-sql-driver/mysql v1.7.0 // indirect
-	github.com/julienschmidt/httprouter v1.3.0 // indirect
-	github.com/justinas/alice v1.2.0 // indirect
-	github.com/justinas/nosurf v1.1.1 // indirect
-	golang.org/x/crypto v0.6.0 // indirect
+	github.com/alecthomas/chroma/v2 v2.14.0
+	github.com/alexedwards/scs/mysqlstore v0.0.0-20230217120314-6b1bedc0f08c
+	github.com/alexedwards/scs/v2 v2.5.0
+	github.com/go-playground/form/v4 v4.2.0
+	github.com/go-sql-driver/mysql v1.7.0
+	github.com/julienschmidt/httprouter v1.3.0
+	github.com/justinas/alice v1.2.0
+	github.com/justinas/nosurf v1.1.1
+	golang.org/x/crypto v0.6.0
 )
+
+require github.com/dlclark/regexp2 v1.11.0 // indirect
@@ -0,0 +1,376 @@
+// Package diff computes line based differences between two texts using the
+// Myers algorithm and renders them as unified or side-by-side diffs.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+func (op Op) String() string {
+	switch op {
+	case Delete:
+		return "delete"
+	case Insert:
+		return "insert"
+	default:
+		return "equal"
+	}
+}
+
+// maxEdits bounds the number of edits Myers algorithm looks for. Texts that
+// differ more than that are reported as entirely replaced, which keeps memory
+// use in check for huge, unrelated snippets.
+const maxEdits = 1000
+
+// Line is a single line of a diff. OldNumber and NewNumber are line numbers
+// counted from 1 in the old and new text, 0 when the line is missing from
+// that text. NoNewline is set on the last line of a text that doesn't end
+// with a newline.
+type Line struct {
+	Op        Op
+	Text      string
+	OldNumber int
+	NewNumber int
+	NoNewline bool
+}
+
+// Hunk is a group of changed lines surrounded by unchanged context lines
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+}
+
+// Header returns the unified diff header of the hunk, e.g. "@@ -1,3 +1,4 @@"
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+}
+
+func hunkRange(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// Row is a single row of a side-by-side diff. Either side is nil when the
+// line has no counterpart in the other text.
+type Row struct {
+	Left  *Line
+	Right *Line
+}
+
+// SideBySide pairs removed lines of the hunk with lines added in their place,
+// so that they can be displayed next to each other
+func (h Hunk) SideBySide() []Row {
+	var rows []Row
+
+	for i := 0; i < len(h.Lines); {
+		line := &h.Lines[i]
+		if line.Op == Equal {
+			rows = append(rows, Row{Left: line, Right: line})
+			i++
+			continue
+		}
+
+		var deleted, inserted []*Line
+		for ; i < len(h.Lines) && h.Lines[i].Op == Delete; i++ {
+			deleted = append(deleted, &h.Lines[i])
+		}
+		for ; i < len(h.Lines) && h.Lines[i].Op == Insert; i++ {
+			inserted = append(inserted, &h.Lines[i])
+		}
+
+		for j := 0; j < len(deleted) || j < len(inserted); j++ {
+			var row Row
+			if j < len(deleted) {
+				row.Left = deleted[j]
+			}
+			if j < len(inserted) {
+				row.Right = inserted[j]
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+// Lines computes line based difference between oldText and newText
+func Lines(oldText, newText string) []Line {
+	a, b := splitLines(oldText), splitLines(newText)
+
+	// Common prefix and suffix are trimmed before running Myers algorithm,
+	// as edits of snippets tend to be local.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]Op, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, Equal)
+	}
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for i := 0; i < suffix; i++ {
+		ops = append(ops, Equal)
+	}
+
+	lines := make([]Line, 0, len(ops))
+	x, y := 0, 0
+	for _, op := range ops {
+		var text string
+		line := Line{Op: op}
+
+		switch op {
+		case Equal:
+			text = a[x]
+			x++
+			y++
+			line.OldNumber, line.NewNumber = x, y
+		case Delete:
+			text = a[x]
+			x++
+			line.OldNumber = x
+		case Insert:
+			text = b[y]
+			y++
+			line.NewNumber = y
+		}
+
+		line.Text = strings.TrimSuffix(text, "\n")
+		line.NoNewline = line.Text == text
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// myers returns the shortest sequence of operations turning a into b
+func myers(a, b []string) []Op {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(n, m)
+	}
+
+	limit := n + m
+	if limit > maxEdits {
+		limit = maxEdits
+	}
+
+	// v[offset+k] holds the furthest x reached on diagonal k; trace keeps
+	// a copy of v from before every round so that the path can be recovered.
+	offset := limit + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(trace, offset, n, m)
+			}
+		}
+	}
+
+	return replaceAll(n, m)
+}
+
+func backtrack(trace [][]int, offset, x, y int) []Op {
+	var ops []Op
+
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, Equal)
+			x--
+			y--
+		}
+
+		if x == prevX {
+			ops = append(ops, Insert)
+		} else {
+			ops = append(ops, Delete)
+		}
+		x, y = prevX, prevY
+	}
+
+	for x > 0 && y > 0 {
+		ops = append(ops, Equal)
+		x--
+		y--
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+
+	return ops
+}
+
+func replaceAll(n, m int) []Op {
+	ops := make([]Op, 0, n+m)
+	for i := 0; i < n; i++ {
+		ops = append(ops, Delete)
+	}
+	for i := 0; i < m; i++ {
+		ops = append(ops, Insert)
+	}
+	return ops
+}
+
+// Hunks groups changed lines together with up to context unchanged lines
+// around them. Changes closer to each other than twice the context end up
+// in a single hunk.
+func Hunks(lines []Line, context int) []Hunk {
+	var hunks []Hunk
+
+	// oldCount and newCount hold the number of lines of each text preceding index i
+	oldCount := make([]int, len(lines)+1)
+	newCount := make([]int, len(lines)+1)
+	for i, line := range lines {
+		oldCount[i+1], newCount[i+1] = oldCount[i], newCount[i]
+		if line.Op != Insert {
+			oldCount[i+1]++
+		}
+		if line.Op != Delete {
+			newCount[i+1]++
+		}
+	}
+
+	for i := 0; i < len(lines); {
+		if lines[i].Op == Equal {
+			i++
+			continue
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+
+		end := i
+		for j := i; j < len(lines) && j <= end+2*context; j++ {
+			if lines[j].Op != Equal {
+				end = j
+			}
+		}
+		i = end + 1
+
+		end += context + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		hunk := Hunk{
+			OldStart: oldCount[start] + 1,
+			OldLines: oldCount[end] - oldCount[start],
+			NewStart: newCount[start] + 1,
+			NewLines: newCount[end] - newCount[start],
+			Lines:    lines[start:end],
+		}
+		if hunk.OldLines == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart--
+		}
+
+		hunks = append(hunks, hunk)
+	}
+
+	return hunks
+}
+
+// Unified renders hunks as a unified diff, that can be applied with patch
+func Unified(oldName, newName string, hunks []Hunk) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+
+	for _, hunk := range hunks {
+		b.WriteString(hunk.Header())
+		b.WriteByte('\n')
+
+		for _, line := range hunk.Lines {
+			switch line.Op {
+			case Equal:
+				b.WriteByte(' ')
+			case Delete:
+				b.WriteByte('-')
+			case Insert:
+				b.WriteByte('+')
+			}
+			b.WriteString(line.Text)
+			b.WriteByte('\n')
+
+			if line.NoNewline {
+				b.WriteString("\\ No newline at end of file\n")
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// splitLines splits text into lines, keeping their newline terminators so that
+// a missing newline at the end of text is reported as a change. Windows line
+// endings are normalised first, as browsers submit textarea content with them.
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
@@ -0,0 +1,207 @@
+package diff
+
+import (
+	"math/rand"
+	"snippetbox/internal/assert"
+	"strings"
+	"testing"
+)
+
+// render flattens lines into a compact form, e.g. " a|-b|+c"
+func render(lines []Line) string {
+	var parts []string
+	for _, line := range lines {
+		prefix := map[Op]string{Equal: " ", Delete: "-", Insert: "+"}[line.Op]
+		parts = append(parts, prefix+line.Text)
+	}
+	return strings.Join(parts, "|")
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		want    string
+	}{
+		{
+			name:    "Identical",
+			oldText: "a\nb\n",
+			newText: "a\nb\n",
+			want:    " a| b",
+		},
+		{
+			name:    "Both empty",
+			oldText: "",
+			newText: "",
+			want:    "",
+		},
+		{
+			name:    "From empty",
+			oldText: "",
+			newText: "a\nb\n",
+			want:    "+a|+b",
+		},
+		{
+			name:    "To empty",
+			oldText: "a\nb\n",
+			newText: "",
+			want:    "-a|-b",
+		},
+		{
+			name:    "Changed line",
+			oldText: "a\nb\nc\n",
+			newText: "a\nx\nc\n",
+			want:    " a|-b|+x| c",
+		},
+		{
+			name:    "Inserted and deleted",
+			oldText: "a\nb\nc\nd\n",
+			newText: "b\nc\ne\nd\n",
+			want:    "-a| b| c|+e| d",
+		},
+		{
+			name:    "Myers example",
+			oldText: "A\nB\nC\nA\nB\nB\nA\n",
+			newText: "C\nB\nA\nB\nA\nC\n",
+			want:    "-A|-B| C|+B| A| B|-B| A|+C",
+		},
+		{
+			name:    "Missing newline at end",
+			oldText: "a\nb",
+			newText: "a\nb\n",
+			want:    " a|-b|+b",
+		},
+		{
+			name:    "Windows line endings",
+			oldText: "a\r\nb\r\n",
+			newText: "a\nb\n",
+			want:    " a| b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, render(Lines(tt.oldText, tt.newText)), tt.want)
+		})
+	}
+}
+
+func TestLinesNumbers(t *testing.T) {
+	lines := Lines("a\nb\nc\n", "a\nx\nc\n")
+
+	assert.Equal(t, len(lines), 4)
+	assert.Equal(t, lines[1].OldNumber, 2)
+	assert.Equal(t, lines[1].NewNumber, 0)
+	assert.Equal(t, lines[2].OldNumber, 0)
+	assert.Equal(t, lines[2].NewNumber, 2)
+	assert.Equal(t, lines[3].OldNumber, 3)
+	assert.Equal(t, lines[3].NewNumber, 3)
+}
+
+func TestLinesTooManyEdits(t *testing.T) {
+	var a, b strings.Builder
+	for i := 0; i < maxEdits; i++ {
+		a.WriteString("a\n")
+		b.WriteString("b\n")
+	}
+
+	lines := Lines(a.String(), b.String())
+
+	assert.Equal(t, len(lines), 2*maxEdits)
+	assert.Equal(t, lines[0].Op, Delete)
+	assert.Equal(t, lines[maxEdits].Op, Insert)
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		want    string
+	}{
+		{
+			name:    "No changes",
+			oldText: "a\n",
+			newText: "a\n",
+			want:    "",
+		},
+		{
+			name:    "Single hunk",
+			oldText: "1\n2\n3\n4\n5\n6\n7\n8\n",
+			newText: "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			want:    "--- a\n+++ b\n@@ -4,3 +4,3 @@\n 4\n-5\n+five\n 6\n",
+		},
+		{
+			name:    "Two hunks",
+			oldText: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			newText: "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			want:    "--- a\n+++ b\n@@ -1,2 +1,2 @@\n-1\n+one\n 2\n@@ -9,2 +9,2 @@\n 9\n-10\n+ten\n",
+		},
+		{
+			name:    "Insertion into empty",
+			oldText: "",
+			newText: "a\n",
+			want:    "--- a\n+++ b\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name:    "No newline at end",
+			oldText: "a\n",
+			newText: "a\nb",
+			want:    "--- a\n+++ b\n@@ -1 +1,2 @@\n a\n+b\n\\ No newline at end of file\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hunks := Hunks(Lines(tt.oldText, tt.newText), 1)
+
+			assert.Equal(t, Unified("a", "b", hunks), tt.want)
+		})
+	}
+}
+
+func TestSideBySide(t *testing.T) {
+	hunks := Hunks(Lines("a\nb\nc\nd\n", "a\nx\nd\n"), 3)
+
+	assert.Equal(t, len(hunks), 1)
+
+	rows := hunks[0].SideBySide()
+
+	assert.Equal(t, len(rows), 4)
+	assert.Equal(t, rows[1].Left.Text, "b")
+	assert.Equal(t, rows[1].Right.Text, "x")
+	assert.Equal(t, rows[2].Left.Text, "c")
+	assert.Equal(t, rows[2].Right == nil, true)
+	assert.Equal(t, rows[3].Left, rows[3].Right)
+}
+
+func TestLinesReconstruct(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	randomText := func() string {
+		var b strings.Builder
+		for i := rng.Intn(20); i > 0; i-- {
+			b.WriteString(string(rune('a' + rng.Intn(4))))
+			b.WriteByte('\n')
+		}
+		return b.String()
+	}
+
+	for i := 0; i < 500; i++ {
+		oldText, newText := randomText(), randomText()
+
+		var gotOld, gotNew strings.Builder
+		for _, line := range Lines(oldText, newText) {
+			if line.Op != Insert {
+				gotOld.WriteString(line.Text + "\n")
+			}
+			if line.Op != Delete {
+				gotNew.WriteString(line.Text + "\n")
+			}
+		}
+
+		assert.Equal(t, gotOld.String(), oldText)
+		assert.Equal(t, gotNew.String(), newText)
+	}
+}
@@ -0,0 +1,153 @@
+// Package highlight renders snippet content as syntax highlighted HTML. Tokens
+// are marked with CSS classes rather than inline styles, to comply with the
+// Content-Security-Policy of the application; the matching stylesheet lives in
+// ui/static/css/highlight.css and can be regenerated with WriteCSS.
+package highlight
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// Language is a language snippets can be highlighted as. Name is the value
+// stored with a snippet and is also the chroma lexer alias. Extension is the
+// file name extension of the language, including the dot.
+type Language struct {
+	Name      string
+	Label     string
+	Extension string
+}
+
+// Languages lists the supported languages, plain text first. Snippets with no
+// language set are highlighted as plain text too.
+var Languages = []Language{
+	{Name: "text", Label: "Plain text", Extension: ".txt"},
+	{Name: "bash", Label: "Bash", Extension: ".sh"},
+	{Name: "c", Label: "C", Extension: ".c"},
+	{Name: "cpp", Label: "C++", Extension: ".cpp"},
+	{Name: "csharp", Label: "C#", Extension: ".cs"},
+	{Name: "css", Label: "CSS", Extension: ".css"},
+	{Name: "docker", Label: "Dockerfile", Extension: ".dockerfile"},
+	{Name: "go", Label: "Go", Extension: ".go"},
+	{Name: "html", Label: "HTML", Extension: ".html"},
+	{Name: "java", Label: "Java", Extension: ".java"},
+	{Name: "javascript", Label: "JavaScript", Extension: ".js"},
+	{Name: "json", Label: "JSON", Extension: ".json"},
+	{Name: "kotlin", Label: "Kotlin", Extension: ".kt"},
+	{Name: "makefile", Label: "Makefile", Extension: ".mk"},
+	{Name: "markdown", Label: "Markdown", Extension: ".md"},
+	{Name: "php", Label: "PHP", Extension: ".php"},
+	{Name: "python", Label: "Python", Extension: ".py"},
+	{Name: "ruby", Label: "Ruby", Extension: ".rb"},
+	{Name: "rust", Label: "Rust", Extension: ".rs"},
+	{Name: "sql", Label: "SQL", Extension: ".sql"},
+	{Name: "toml", Label: "TOML", Extension: ".toml"},
+	{Name: "typescript", Label: "TypeScript", Extension: ".ts"},
+	{Name: "xml", Label: "XML", Extension: ".xml"},
+	{Name: "yaml", Label: "YAML", Extension: ".yaml"},
+}
+
+// styleName is the chroma style highlight.css is generated from
+const styleName = "github"
+
+// lineFormatter renders the tokens of a single line, without any wrapper
+var lineFormatter = html.New(
+	html.WithClasses(true),
+	html.PreventSurroundingPre(true),
+)
+
+// Names returns names of all supported languages
+func Names() []string {
+	names := make([]string, len(Languages))
+	for i, language := range Languages {
+		names[i] = language.Name
+	}
+	return names
+}
+
+// Label returns the human readable name of language with given name
+func Label(name string) string {
+	for _, language := range Languages {
+		if language.Name == name {
+			return language.Label
+		}
+	}
+	return Languages[0].Label
+}
+
+// Extension returns the file name extension of language with given name
+func Extension(name string) string {
+	for _, language := range Languages {
+		if language.Name == name {
+			return language.Extension
+		}
+	}
+	return Languages[0].Extension
+}
+
+// tokenise splits content into tokens of given language, falling back to plain
+// text for unknown languages
+func tokenise(content, language string) (chroma.Iterator, error) {
+	lexer := lexers.Get(language)
+	if language == "" || lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	return lexer.Tokenise(nil, strings.ReplaceAll(content, "\r\n", "\n"))
+}
+
+// Line is a single highlighted line of content, numbered from 1. HTML includes
+// the line break ending the line, if any.
+type Line struct {
+	Number int
+	HTML   template.HTML
+}
+
+// Lines renders content highlighted as given language line by line, for pages
+// laying out the lines themselves. It splits content into as many lines as
+// CountLines. Unknown languages are rendered as plain text.
+func Lines(content, language string) ([]Line, error) {
+	iterator, err := tokenise(content, language)
+	if err != nil {
+		return nil, err
+	}
+
+	style := styles.Get(styleName)
+
+	var lines []Line
+	for i, tokens := range chroma.SplitTokensIntoLines(iterator.Tokens()) {
+		var buf bytes.Buffer
+
+		err = lineFormatter.Format(&buf, style, chroma.Literator(tokens...))
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, Line{Number: i + 1, HTML: template.HTML(buf.String())})
+	}
+
+	return lines, nil
+}
+
+// CountLines returns the number of lines of content, a line break at the end
+// not starting another line
+func CountLines(content string) int {
+	if content == "" {
+		return 0
+	}
+	content = strings.TrimSuffix(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	return strings.Count(content, "\n") + 1
+}
+
+// WriteCSS writes the stylesheet matching classes used by Lines
+func WriteCSS(w io.Writer) error {
+	return lineFormatter.WriteCSS(w, styles.Get(styleName))
+}
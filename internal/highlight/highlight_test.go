@@ -0,0 +1,97 @@
+package highlight
+
+import (
+	"snippetbox/internal/assert"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+func TestLanguagesHaveLexers(t *testing.T) {
+	for _, language := range Languages {
+		t.Run(language.Label, func(t *testing.T) {
+			assert.Equal(t, lexers.Get(language.Name) != nil, true)
+		})
+	}
+}
+
+func TestExtension(t *testing.T) {
+	assert.Equal(t, Extension("go"), ".go")
+	assert.Equal(t, Extension("bash"), ".sh")
+	assert.Equal(t, Extension(""), ".txt")
+	assert.Equal(t, Extension("foo"), ".txt")
+}
+
+func TestLabel(t *testing.T) {
+	assert.Equal(t, Label("go"), "Go")
+	assert.Equal(t, Label(""), "Plain text")
+	assert.Equal(t, Label("foo"), "Plain text")
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		language  string
+		wantLines int
+		wantLast  string
+	}{
+		{
+			name:      "Trailing line break",
+			language:  "go",
+			content:   "package main\n\nfunc main() {}\n",
+			wantLines: 3,
+			wantLast:  `<span class="kd">func</span>`,
+		},
+		{
+			name:      "No trailing line break",
+			language:  "go",
+			content:   "a\nb",
+			wantLines: 2,
+			wantLast:  "b",
+		},
+		{
+			name:      "Blank last line",
+			language:  "go",
+			content:   "a\n\n",
+			wantLines: 2,
+			wantLast:  "\n",
+		},
+		{
+			name:      "Windows line breaks",
+			language:  "go",
+			content:   "a\r\nb\r\n",
+			wantLines: 2,
+			wantLast:  "b",
+		},
+		{
+			name:      "Escaped content",
+			language:  "go",
+			content:   "<script>",
+			wantLines: 1,
+			wantLast:  `<span class="p">&lt;</span>`,
+		},
+		{
+			name:      "Unknown language",
+			language:  "foo",
+			content:   "<b>text</b>",
+			wantLines: 1,
+			wantLast:  "&lt;b&gt;text&lt;/b&gt;",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := Lines(tt.content, tt.language)
+			assert.NilError(t, err)
+
+			assert.Equal(t, len(lines), tt.wantLines)
+			assert.Equal(t, CountLines(tt.content), tt.wantLines)
+			if len(lines) > 0 {
+				last := lines[len(lines)-1]
+				assert.Equal(t, last.Number, len(lines))
+				assert.StringContains(t, string(last.HTML), tt.wantLast)
+			}
+		})
+	}
+}
@@ -0,0 +1,360 @@
+// Package langdetect guesses the programming language of a snippet. The
+// returned names match the language names of the highlight package.
+package langdetect
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// rule is a pattern typical for a language. Every match adds weight to the
+// language score, up to maxMatches matches per rule.
+type rule struct {
+	language string
+	weight   int
+	pattern  *regexp.Regexp
+}
+
+const (
+	maxMatches = 5
+	// minScore is the lowest keyword score accepted as a detection
+	minScore = 4
+)
+
+var interpreters = map[string]string{
+	"sh":     "bash",
+	"bash":   "bash",
+	"zsh":    "bash",
+	"dash":   "bash",
+	"ksh":    "bash",
+	"python": "python",
+	"ruby":   "ruby",
+	"node":   "javascript",
+	"nodejs": "javascript",
+	"deno":   "typescript",
+	"php":    "php",
+	"make":   "makefile",
+}
+
+var extensions = map[string]string{
+	"go":   "go",
+	"py":   "python",
+	"rb":   "ruby",
+	"js":   "javascript",
+	"mjs":  "javascript",
+	"cjs":  "javascript",
+	"ts":   "typescript",
+	"c":    "c",
+	"h":    "c",
+	"cpp":  "cpp",
+	"cc":   "cpp",
+	"cxx":  "cpp",
+	"hpp":  "cpp",
+	"cs":   "csharp",
+	"css":  "css",
+	"html": "html",
+	"htm":  "html",
+	"java": "java",
+	"json": "json",
+	"kt":   "kotlin",
+	"kts":  "kotlin",
+	"md":   "markdown",
+	"php":  "php",
+	"rs":   "rust",
+	"sql":  "sql",
+	"toml": "toml",
+	"xml":  "xml",
+	"yaml": "yaml",
+	"yml":  "yaml",
+	"sh":   "bash",
+	"bash": "bash",
+	"mk":   "makefile",
+}
+
+// fileNames are file names without meaningful extension that identify a language
+var fileNames = map[string]string{
+	"dockerfile":    "docker",
+	"containerfile": "docker",
+	"makefile":      "makefile",
+	"gnumakefile":   "makefile",
+	"gemfile":       "ruby",
+	"rakefile":      "ruby",
+	".bashrc":       "bash",
+	".zshrc":        "bash",
+	".profile":      "bash",
+}
+
+var (
+	rxFileName = regexp.MustCompile(`[\w.-]+`)
+	rxVersion  = regexp.MustCompile(`[\d.]+$`)
+)
+
+var rules = compileRules(map[string]map[string]int{
+	"go": {
+		`^package \w+$`:                  5,
+		`^import \($`:                    3,
+		`\bfunc (\(\w+ \*?\w+\) )?\w+\(`: 3,
+		`:=`:                             1,
+		`\bfmt\.\w+\(`:                   2,
+		`\b(chan|defer|go func)\b`:       2,
+		`\berr != nil\b`:                 3,
+	},
+	"python": {
+		`^\s*def \w+\(.*\):\s*$`:      4,
+		`^\s*class \w+(\(.*\))?:\s*$`: 3,
+		`^from [\w.]+ import `:        4,
+		`^import \w+$`:                1,
+		`\bself\.`:                    2,
+		`\belif\b`:                    3,
+		`\bprint\(`:                   1,
+		`^if __name__ == `:            5,
+		`\b(None|True|False)\b`:       1,
+	},
+	"javascript": {
+		`\b(const|let) \w+ = `: 2,
+		`\bfunction\s*\w*\(`:   2,
+		`=>`:                   1,
+		`\bconsole\.log\(`:     3,
+		`\bdocument\.\w+`:      3,
+		`\brequire\(['"]`:      3,
+		`===|!==`:              2,
+		`\bmodule\.exports\b`:  4,
+	},
+	"typescript": {
+		`\w\??: (string|number|boolean|any|void)\b`: 3,
+		`^\s*(export )?interface \w+ \{`:            4,
+		`^\s*(export )?type \w+ = `:                 4,
+		`\b(const|let) \w+ = `:                      1,
+		`=>`:                                        1,
+	},
+	"java": {
+		`\bpublic (static )?(final )?(class|void|int|String)\b`: 3,
+		`\bSystem\.out\.print`:        4,
+		`^import java\.`:              5,
+		`@Override\b`:                 3,
+		`\bString\[\] args\b`:         3,
+		`\bprivate (final )?\w+ \w+;`: 2,
+	},
+	"c": {
+		`^#include <\w+\.h>`:       4,
+		`\bprintf\(`:               2,
+		`\bint main\(`:             2,
+		`\b(malloc|free|sizeof)\(`: 2,
+		`\bstruct \w+ \{`:          1,
+	},
+	"cpp": {
+		`^#include <\w+>`:     4,
+		`\bstd::`:             3,
+		`\b(cout|cin|endl)\b`: 2,
+		`\btemplate\s*<`:      3,
+		`\bint main\(`:        1,
+	},
+	"csharp": {
+		`^using System`:                     5,
+		`\bConsole\.Write`:                  4,
+		`^namespace [\w.]+`:                 3,
+		`\bpublic (static )?(class|void)\b`: 1,
+		`\b(var|string) \w+ = `:             1,
+	},
+	"rust": {
+		`\bfn \w+\(`:             3,
+		`\blet mut\b`:            4,
+		`\bprintln!\(`:           4,
+		`^\s*impl\b`:             3,
+		`^use \w+::`:             3,
+		`\b(Some|None|Ok|Err)\(`: 1,
+		`&mut\b`:                 2,
+	},
+	"ruby": {
+		`^\s*def \w+[?!]?(\(.*\))?\s*$`: 3,
+		`^\s*end$`:                      2,
+		`\bputs\b`:                      3,
+		`^require ['"]`:                 3,
+		`\.each do\b`:                   4,
+		`\battr_(accessor|reader)\b`:    4,
+	},
+	"php": {
+		`<\?php`:    10,
+		`\$\w+\s*=`: 1,
+		`\becho\b`:  1,
+		`\$this->`:  3,
+	},
+	"sql": {
+		`(?i)\bselect\b.+\bfrom\b`:     4,
+		`(?i)\binsert into\b`:          4,
+		`(?i)\bcreate (table|index)\b`: 4,
+		`(?i)\bwhere\b`:                1,
+		`(?i)\bupdate \w+ set\b`:       4,
+		`(?i)\b(inner|left) join\b`:    2,
+	},
+	"bash": {
+		`^\s*(if|while) \[`:   4,
+		`^\s*(fi|done|esac)$`: 3,
+		`^\s*echo\b`:          2,
+		`\$\{\w+\}`:           2,
+		`^\s*export \w+=`:     3,
+		`^\s*(sudo|apt-get|apt|cd|ls|mkdir|curl) `: 2,
+	},
+	"html": {
+		`(?i)<!doctype html>`:                          10,
+		`<(html|head|body|div|span|p|a|ul|li)\b[^>]*>`: 2,
+		`</\w+>`: 1,
+	},
+	"xml": {
+		`^<\?xml `:      10,
+		`</[\w:]+>`:     1,
+		`xmlns(:\w+)?=`: 3,
+	},
+	"css": {
+		`^\s*[.#]?[\w-]+( [.#]?[\w-]+)*\s*\{\s*$`: 2,
+		`^\s*[\w-]+:\s*[^;]+;\s*$`:                2,
+		`^\s*@media\b`:                            3,
+	},
+	"yaml": {
+		`^---$`:                2,
+		`^\s*[\w-]+:( \S.*)?$`: 1,
+		`^\s*- [\w-]+:? ?`:     1,
+		`^apiVersion: `:        4,
+	},
+	"toml": {
+		`^\[[\w.-]+\]$`:                  3,
+		`^\[\[[\w.-]+\]\]$`:              4,
+		`^[\w-]+ = ("|\d|\[|true|false)`: 2,
+	},
+	"docker": {
+		`^FROM \S+`: 5,
+		`^(RUN|COPY|ADD|CMD|ENTRYPOINT|WORKDIR|EXPOSE|ENV|ARG) `: 3,
+	},
+	"makefile": {
+		`^\.PHONY:`:              6,
+		`^[\w.-]+:( [\w./-]+)*$`: 2,
+		`\$\(\w+\)`:              1,
+		`^\t\S`:                  1,
+	},
+	"markdown": {
+		"^#{1,6} \\S":         2,
+		"^```":                3,
+		`\[[^\]]+\]\([^)]+\)`: 3,
+		`^\s*[-*] \S`:         1,
+		`\*\*\S[^*]*\*\*`:     2,
+	},
+	"kotlin": {
+		`\bfun \w+\(`:            4,
+		`\bval \w+( ?: ?\w+)? =`: 3,
+		`\bprintln\(`:            2,
+		`^package [\w.]+$`:       1,
+	},
+})
+
+func compileRules(patterns map[string]map[string]int) []rule {
+	var res []rule
+
+	for language, weights := range patterns {
+		for pattern, weight := range weights {
+			res = append(res, rule{
+				language: language,
+				weight:   weight,
+				pattern:  regexp.MustCompile("(?m)" + pattern),
+			})
+		}
+	}
+
+	return res
+}
+
+// Detect guesses the language of content. A shebang line is trusted first,
+// then a file name mentioned in the title, and finally the language whose
+// typical keywords and constructs appear most often in content. Empty string
+// is returned when none of these yield a confident guess.
+func Detect(title, content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	if language, ok := FromShebang(content); ok {
+		return language
+	}
+
+	if language, ok := FromFileName(title); ok {
+		return language
+	}
+
+	return FromKeywords(content)
+}
+
+// FromShebang detects the language from interpreter named in the first line
+// of content, e.g. "#!/usr/bin/env python3"
+func FromShebang(content string) (string, bool) {
+	if !strings.HasPrefix(content, "#!") {
+		return "", false
+	}
+
+	line, _, _ := strings.Cut(content[2:], "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", false
+	}
+
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = field
+				break
+			}
+		}
+	}
+
+	language, ok := interpreters[rxVersion.ReplaceAllString(interpreter, "")]
+	return language, ok
+}
+
+// FromFileName detects the language from a file name found in title, e.g.
+// "main.go" or "Dockerfile for the API"
+func FromFileName(title string) (string, bool) {
+	for _, word := range rxFileName.FindAllString(strings.ToLower(title), -1) {
+		if language, ok := fileNames[word]; ok {
+			return language, true
+		}
+
+		dot := strings.LastIndexByte(word, '.')
+		if dot <= 0 || dot == len(word)-1 {
+			continue
+		}
+		if language, ok := extensions[word[dot+1:]]; ok && language != "" {
+			return language, true
+		}
+	}
+
+	return "", false
+}
+
+// FromKeywords detects the language by scoring content against patterns
+// typical for each language
+func FromKeywords(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return ""
+	}
+
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+		return "json"
+	}
+
+	scores := map[string]int{}
+	for _, r := range rules {
+		matches := len(r.pattern.FindAllStringIndex(content, maxMatches))
+		scores[r.language] += matches * r.weight
+	}
+
+	best, bestScore := "", minScore-1
+	for language, score := range scores {
+		// ties are resolved alphabetically, to keep the result stable
+		if score > bestScore || score == bestScore && best != "" && language < best {
+			best, bestScore = language, score
+		}
+	}
+
+	return best
+}
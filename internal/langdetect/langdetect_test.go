@@ -0,0 +1,363 @@
+package langdetect
+
+import (
+	"snippetbox/internal/assert"
+	"snippetbox/internal/highlight"
+	"snippetbox/internal/validator"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		title   string
+		content string
+		want    string
+	}{
+		{
+			name:    "Shebang env python",
+			title:   "Cleanup",
+			content: "#!/usr/bin/env python3\nprint('hi')\n",
+			want:    "python",
+		},
+		{
+			name:    "Shebang bash",
+			title:   "Bootstrap main.go",
+			content: "#!/bin/bash\nset -e\n",
+			want:    "bash",
+		},
+		{
+			name:    "Shebang env with flags",
+			title:   "Script",
+			content: "#!/usr/bin/env -S node --harmony\nx()\n",
+			want:    "javascript",
+		},
+		{
+			name:    "Unknown shebang falls through",
+			title:   "notes.md",
+			content: "#!/usr/bin/awk -f\n{ print }\n",
+			want:    "markdown",
+		},
+		{
+			name:    "Extension in title",
+			title:   "Our k8s deployment.yaml",
+			content: "whatever",
+			want:    "yaml",
+		},
+		{
+			name:    "File name in title",
+			title:   "Dockerfile for the API",
+			content: "whatever",
+			want:    "docker",
+		},
+		{
+			name:    "Version number is not an extension",
+			title:   "Release 1.2",
+			content: "",
+			want:    "",
+		},
+		{
+			name:    "Empty",
+			title:   "Nothing",
+			content: "   \n",
+			want:    "",
+		},
+		{
+			name:    "Prose",
+			title:   "Haiku",
+			content: "An old silent pond\nA frog jumps into the pond\nSplash! Silence again.\n",
+			want:    "",
+		},
+		{
+			name:  "Go",
+			title: "Server",
+			content: `package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+func main() {
+	err := http.ListenAndServe(":4000", nil)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+`,
+			want: "go",
+		},
+		{
+			name:  "Python",
+			title: "Fibonacci",
+			content: `from functools import lru_cache
+
+@lru_cache
+def fib(n):
+    if n < 2:
+        return n
+    elif n == 2:
+        return 1
+    return fib(n - 1) + fib(n - 2)
+
+if __name__ == "__main__":
+    print(fib(10))
+`,
+			want: "python",
+		},
+		{
+			name:  "JavaScript",
+			title: "Fetch helper",
+			content: `const fetch = require('node-fetch');
+
+function get(url) {
+  return fetch(url).then(res => res.json());
+}
+
+module.exports = { get };
+`,
+			want: "javascript",
+		},
+		{
+			name:  "TypeScript",
+			title: "User type",
+			content: `export interface User {
+  id: number;
+  name: string;
+}
+
+export type Handler = (user: User) => void;
+`,
+			want: "typescript",
+		},
+		{
+			name:  "Java",
+			title: "Hello",
+			content: `import java.util.List;
+
+public class Hello {
+    public static void main(String[] args) {
+        System.out.println("Hello");
+    }
+}
+`,
+			want: "java",
+		},
+		{
+			name:  "C",
+			title: "Hello",
+			content: `#include <stdio.h>
+#include <stdlib.h>
+
+int main(void) {
+    char *buf = malloc(16);
+    printf("hello\n");
+    free(buf);
+    return 0;
+}
+`,
+			want: "c",
+		},
+		{
+			name:  "C++",
+			title: "Vector",
+			content: `#include <iostream>
+#include <vector>
+
+int main() {
+    std::vector<int> v{1, 2, 3};
+    for (auto i : v) std::cout << i << std::endl;
+}
+`,
+			want: "cpp",
+		},
+		{
+			name:  "C#",
+			title: "Hello",
+			content: `using System;
+
+namespace Demo
+{
+    class Program
+    {
+        static void Main() => Console.WriteLine("Hello");
+    }
+}
+`,
+			want: "csharp",
+		},
+		{
+			name:  "Rust",
+			title: "Counter",
+			content: `use std::collections::HashMap;
+
+fn main() {
+    let mut counts = HashMap::new();
+    counts.insert("a", 1);
+    println!("{:?}", counts);
+}
+`,
+			want: "rust",
+		},
+		{
+			name:  "Ruby",
+			title: "Greeter",
+			content: `require 'json'
+
+class Greeter
+  attr_reader :name
+
+  def greet
+    [1, 2].each do |i|
+      puts "Hello #{name} #{i}"
+    end
+  end
+end
+`,
+			want: "ruby",
+		},
+		{
+			name:    "PHP",
+			title:   "Index",
+			content: "<?php\n$name = 'world';\necho \"Hello $name\";\n",
+			want:    "php",
+		},
+		{
+			name:  "SQL",
+			title: "Active users",
+			content: `SELECT u.id, u.name FROM users u
+INNER JOIN snippets s ON s.user_id = u.id
+WHERE s.expires > UTC_TIMESTAMP();
+`,
+			want: "sql",
+		},
+		{
+			name:  "Bash without shebang",
+			title: "Install",
+			content: `export GOPATH=$HOME/go
+if [ ! -d "${GOPATH}" ]; then
+  mkdir -p "${GOPATH}"
+fi
+echo done
+`,
+			want: "bash",
+		},
+		{
+			name:    "HTML",
+			title:   "Page",
+			content: "<!DOCTYPE html>\n<html>\n<body><p>Hi</p></body>\n</html>\n",
+			want:    "html",
+		},
+		{
+			name:    "XML",
+			title:   "Config",
+			content: "<?xml version=\"1.0\"?>\n<config><item>1</item></config>\n",
+			want:    "xml",
+		},
+		{
+			name:  "CSS",
+			title: "Styles",
+			content: `body {
+    margin: 0;
+    color: #34495E;
+}
+
+.snippet pre {
+    padding: 18px;
+}
+`,
+			want: "css",
+		},
+		{
+			name:    "JSON",
+			title:   "Payload",
+			content: `{"title": "O snail", "expires": 7}`,
+			want:    "json",
+		},
+		{
+			name:  "YAML",
+			title: "Deployment",
+			content: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: snippetbox
+spec:
+  replicas: 2
+`,
+			want: "yaml",
+		},
+		{
+			name:  "TOML",
+			title: "Settings",
+			content: `[server]
+port = 4000
+debug = false
+
+[[users]]
+name = "alice"
+`,
+			want: "toml",
+		},
+		{
+			name:  "Dockerfile",
+			title: "Image",
+			content: `FROM golang:1.19
+WORKDIR /app
+COPY . .
+RUN go build ./cmd/web
+CMD ["./web"]
+`,
+			want: "docker",
+		},
+		{
+			name:    "Makefile",
+			title:   "Build",
+			content: ".PHONY: build test\n\nbuild:\n\tgo build $(FLAGS) ./...\n\ntest:\n\tgo test ./...\n",
+			want:    "makefile",
+		},
+		{
+			name:    "Markdown",
+			title:   "Readme",
+			content: "# SnippetBox\n\nSee [the book](https://lets-go.alexedwards.net/).\n\n- **Fast**\n- Simple\n",
+			want:    "markdown",
+		},
+		{
+			name:  "Kotlin",
+			title: "Main",
+			content: `fun main() {
+    val name: String = "Kotlin"
+    println("Hello, $name")
+}
+`,
+			want: "kotlin",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, Detect(tt.title, tt.content), tt.want)
+		})
+	}
+}
+
+func TestDetectedLanguagesAreSupported(t *testing.T) {
+	detectable := map[string]bool{"json": true}
+	for _, language := range interpreters {
+		detectable[language] = true
+	}
+	for _, language := range extensions {
+		detectable[language] = true
+	}
+	for _, language := range fileNames {
+		detectable[language] = true
+	}
+	for _, r := range rules {
+		detectable[r.language] = true
+	}
+
+	for language := range detectable {
+		t.Run(language, func(t *testing.T) {
+			assert.Equal(t, validator.PermittedValue(language, highlight.Names()...), true)
+		})
+	}
+}
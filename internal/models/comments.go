@@ -0,0 +1,258 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type CommentModelInterface interface {
+	Insert(snippetID, userID, parentID int, lines LineRange, content string) (int, error)
+	Get(id int) (*Comment, error)
+	Thread(snippetID int) ([]*Comment, error)
+	Update(id int, content string) error
+	Delete(id int) error
+}
+
+// LineRange is a range of lines, from Start to End, of a version of a snippet.
+// Versions are numbered like revisions, the current version of a snippet being
+// numbered one past its latest revision, so that the range keeps pointing to
+// the same lines when the snippet is edited.
+type LineRange struct {
+	Version int
+	Start   int
+	End     int
+}
+
+// IsZero reports whether the range is empty, as it is for comments about the
+// snippet as a whole
+func (r LineRange) IsZero() bool {
+	return r == LineRange{}
+}
+
+// Anchor returns the URL fragment of the lines, e.g. L10 or L10-L14, without
+// the leading #
+func (r LineRange) Anchor() string {
+	if r.Start == r.End {
+		return fmt.Sprintf("L%d", r.Start)
+	}
+	return fmt.Sprintf("L%d-L%d", r.Start, r.End)
+}
+
+// Comment is a comment on a snippet, possibly a reply to another comment
+type Comment struct {
+	ID        int
+	SnippetID int
+	UserID    int
+	Author    string
+	// ParentID is the id of the comment this one replies to, 0 for top level
+	// comments
+	ParentID int
+	// Lines are the lines the comment is about, zero for comments about the
+	// whole snippet and for replies
+	Lines   LineRange
+	Content string
+	Created time.Time
+	// Edited is zero for comments that were never edited
+	Edited time.Time
+	// Deleted is set for comments deleted while they had replies. They're kept
+	// without their content so that the replies stay in place.
+	Deleted bool
+	// Depth is the number of comments above this one in its thread, it's set
+	// by Thread only
+	Depth int
+}
+
+type CommentModel struct {
+	DB *sql.DB
+}
+
+// commentFields are the columns scanned by scanComment, every query selecting
+// them has to join users table as u and comments table as c
+const commentFields = `c.id, c.snippet_id, c.user_id, u.name, c.parent_id, c.version, c.line_start, c.line_end,
+	c.content, c.created, c.edited, c.deleted`
+
+func scanComment(row scanner) (*Comment, error) {
+	c := &Comment{}
+
+	var parentID, version, lineStart, lineEnd sql.NullInt64
+	var edited sql.NullTime
+	err := row.Scan(&c.ID, &c.SnippetID, &c.UserID, &c.Author, &parentID, &version, &lineStart, &lineEnd,
+		&c.Content, &c.Created, &edited, &c.Deleted)
+	if err != nil {
+		return nil, err
+	}
+	c.ParentID = int(parentID.Int64)
+	c.Lines = LineRange{Version: int(version.Int64), Start: int(lineStart.Int64), End: int(lineEnd.Int64)}
+	c.Edited = edited.Time
+
+	return c, nil
+}
+
+// Insert adds a comment by user with given userID to snippet with given
+// snippetID and returns its id. The comment is about given lines, unless the
+// range is zero. Unless parentID is 0, the comment replies to comment with that
+// id, which has to be a comment on the same snippet that wasn't deleted,
+// otherwise ErrNoRecord is returned. Replies can't be about lines.
+func (m *CommentModel) Insert(snippetID, userID, parentID int, lines LineRange, content string) (int, error) {
+	var res sql.Result
+	var err error
+
+	if parentID == 0 {
+		stmt := `INSERT INTO comments (snippet_id, user_id, version, line_start, line_end, content, created)
+				VALUES(?, ?, NULLIF(?, 0), NULLIF(?, 0), NULLIF(?, 0), ?, UTC_TIMESTAMP())`
+		res, err = m.DB.Exec(stmt, snippetID, userID, lines.Version, lines.Start, lines.End, content)
+	} else {
+		stmt := `INSERT INTO comments (snippet_id, user_id, parent_id, content, created)
+				SELECT snippet_id, ?, id, ?, UTC_TIMESTAMP() FROM comments
+				WHERE id = ? AND snippet_id = ? AND NOT deleted`
+		res, err = m.DB.Exec(stmt, userID, content, parentID, snippetID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if affected == 0 {
+		return 0, ErrNoRecord
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// Get returns comment with given id, unless it was deleted
+func (m *CommentModel) Get(id int) (*Comment, error) {
+	stmt := `SELECT ` + commentFields + ` FROM comments c
+				INNER JOIN users u ON u.id = c.user_id
+				WHERE c.id = ? AND NOT c.deleted`
+
+	c, err := scanComment(m.DB.QueryRow(stmt, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Thread returns comments on snippet with given snippetID in reading order:
+// oldest first, every comment followed by its replies
+func (m *CommentModel) Thread(snippetID int) ([]*Comment, error) {
+	stmt := `SELECT ` + commentFields + ` FROM comments c
+				INNER JOIN users u ON u.id = c.user_id
+				WHERE c.snippet_id = ? ORDER BY c.id`
+
+	rows, err := m.DB.Query(stmt, snippetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	replies := map[int][]*Comment{}
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		replies[c.ParentID] = append(replies[c.ParentID], c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var thread []*Comment
+
+	var walk func(parentID, depth int)
+	walk = func(parentID, depth int) {
+		for _, c := range replies[parentID] {
+			c.Depth = depth
+			thread = append(thread, c)
+			walk(c.ID, depth+1)
+		}
+	}
+	walk(0, 0)
+
+	return thread, nil
+}
+
+// Update replaces the content of comment with given id
+func (m *CommentModel) Update(id int, content string) error {
+	stmt := `UPDATE comments SET content = ?, edited = UTC_TIMESTAMP() WHERE id = ? AND NOT deleted`
+
+	_, err := m.DB.Exec(stmt, content, id)
+	return err
+}
+
+// Delete removes comment with given id. A comment with replies is only
+// emptied and marked as deleted, it goes away with its last reply.
+func (m *CommentModel) Delete(id int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	parentID, _, hasReplies, err := lockComment(tx, id)
+	if err != nil {
+		return err
+	}
+
+	if hasReplies {
+		_, err = tx.Exec(`UPDATE comments SET content = '', deleted = TRUE WHERE id = ?`, id)
+		if err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	_, err = tx.Exec(`DELETE FROM comments WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	// Removing the comment might have left deleted comments above it with no
+	// replies to keep them for.
+	for parentID != 0 {
+		grandparentID, deleted, hasReplies, err := lockComment(tx, parentID)
+		if err != nil {
+			return err
+		}
+		if !deleted || hasReplies {
+			break
+		}
+
+		_, err = tx.Exec(`DELETE FROM comments WHERE id = ?`, parentID)
+		if err != nil {
+			return err
+		}
+		parentID = grandparentID
+	}
+
+	return tx.Commit()
+}
+
+// lockComment locks comment with given id for the rest of tx and returns its
+// parent id, whether it was deleted and whether it has any replies
+func lockComment(tx *sql.Tx, id int) (parentID int, deleted, hasReplies bool, err error) {
+	stmt := `SELECT c.parent_id, c.deleted, EXISTS(SELECT 1 FROM comments r WHERE r.parent_id = c.id)
+			FROM comments c WHERE c.id = ? FOR UPDATE`
+
+	var parent sql.NullInt64
+	err = tx.QueryRow(stmt, id).Scan(&parent, &deleted, &hasReplies)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = ErrNoRecord
+	}
+
+	return int(parent.Int64), deleted, hasReplies, err
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"errors"
+	"snippetbox/internal/assert"
+	"testing"
+)
+
+func TestCommentModel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := CommentModel{DB: db}
+	snippets := SnippetModel{DB: db}
+
+	first, err := m.Insert(1, 1, 0, LineRange{}, "First")
+	assert.NilError(t, err)
+	second, err := m.Insert(1, 1, 0, LineRange{}, "Second")
+	assert.NilError(t, err)
+	reply, err := m.Insert(1, 1, first, LineRange{}, "Reply")
+	assert.NilError(t, err)
+	nested, err := m.Insert(1, 1, reply, LineRange{}, "Nested reply")
+	assert.NilError(t, err)
+
+	// replies can't cross snippets
+	other, err := snippets.Insert("Another pond", "Another pond...", "", nil, VisibilityPublic, "", 0, nextWeek, 1, 0)
+	assert.NilError(t, err)
+	_, err = m.Insert(other, 1, first, LineRange{}, "Lost")
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+	thread, err := m.Thread(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(thread), 4)
+	if len(thread) == 4 {
+		for i, want := range []struct{ id, depth int }{{first, 0}, {reply, 1}, {nested, 2}, {second, 0}} {
+			assert.Equal(t, thread[i].ID, want.id)
+			assert.Equal(t, thread[i].Depth, want.depth)
+		}
+	}
+
+	assert.NilError(t, m.Update(second, "Second, edited"))
+	comment, err := m.Get(second)
+	assert.NilError(t, err)
+	assert.Equal(t, comment.Content, "Second, edited")
+	assert.Equal(t, comment.Edited.IsZero(), false)
+
+	snippet, err := snippets.Peek(1, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, snippet.Comments, 4)
+
+	// comments with replies are kept without content until their replies go
+	assert.NilError(t, m.Delete(first))
+	_, err = m.Get(first)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+	thread, err = m.Thread(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(thread), 4)
+	if len(thread) == 4 {
+		assert.Equal(t, thread[0].Deleted, true)
+		assert.Equal(t, thread[0].Content, "")
+	}
+
+	_, err = m.Insert(1, 1, first, LineRange{}, "Too late")
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+	assert.NilError(t, m.Delete(reply))
+	assert.NilError(t, m.Delete(nested))
+
+	thread, err = m.Thread(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(thread), 1)
+
+	snippet, err = snippets.Peek(1, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, snippet.Comments, 1)
+
+	err = m.Delete(first)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+}
+
+func TestLineRangeAnchor(t *testing.T) {
+	assert.Equal(t, LineRange{Version: 1, Start: 10, End: 10}.Anchor(), "L10")
+	assert.Equal(t, LineRange{Version: 1, Start: 10, End: 14}.Anchor(), "L10-L14")
+}
+
+func TestCommentModelLines(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := CommentModel{DB: db}
+	snippets := SnippetModel{DB: db}
+
+	lines := LineRange{Version: 1, Start: 1, End: 1}
+
+	id, err := m.Insert(1, 1, 0, lines, "Line comment")
+	assert.NilError(t, err)
+
+	// line comments stay pinned to their version when the snippet is edited
+	assert.NilError(t, snippets.Update(1, "An old silent pond", "A frog jumps into the pond", "", nil, VisibilityPublic, nil, 0, nextWeek))
+
+	comment, err := m.Get(id)
+	assert.NilError(t, err)
+	assert.Equal(t, comment.Lines, lines)
+
+	revision, err := snippets.Revision(1, comment.Lines.Version, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, revision.Content, "An old silent pond...")
+
+	// replies are never about lines
+	reply, err := m.Insert(1, 1, id, LineRange{}, "Reply")
+	assert.NilError(t, err)
+
+	comment, err = m.Get(reply)
+	assert.NilError(t, err)
+	assert.Equal(t, comment.Lines.IsZero(), true)
+}
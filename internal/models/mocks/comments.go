@@ -0,0 +1,90 @@
+package mocks
+
+import (
+	"snippetbox/internal/models"
+	"time"
+)
+
+var mockComment = &models.Comment{
+	ID:        1,
+	SnippetID: 1,
+	UserID:    1,
+	Author:    "test",
+	Content:   "Lovely haiku",
+	Created:   time.Now(),
+}
+
+var mockReply = &models.Comment{
+	ID:        2,
+	SnippetID: 1,
+	UserID:    2,
+	Author:    "someone else",
+	ParentID:  1,
+	Content:   "Thanks, it's a classic",
+	Created:   time.Now(),
+	Depth:     1,
+}
+
+// mockLineComment is about the first line of the current version of mockSnippet
+var mockLineComment = &models.Comment{
+	ID:        4,
+	SnippetID: 1,
+	UserID:    1,
+	Author:    "test",
+	Lines:     models.LineRange{Version: 2, Start: 1, End: 1},
+	Content:   "Maybe drop the ellipsis",
+	Created:   time.Now(),
+}
+
+// mockOutdatedComment is about the first line of mockRevision
+var mockOutdatedComment = &models.Comment{
+	ID:        5,
+	SnippetID: 1,
+	UserID:    2,
+	Author:    "someone else",
+	Lines:     models.LineRange{Version: 1, Start: 1, End: 1},
+	Content:   "Too short",
+	Created:   time.Now(),
+}
+
+type CommentModel struct{}
+
+func (m *CommentModel) Insert(snippetID, userID, parentID int, lines models.LineRange, content string) (int, error) {
+	if snippetID == 1 && (parentID == 0 || parentID == 1 || parentID == 2 || parentID == 4) {
+		return 3, nil
+	}
+
+	return 0, models.ErrNoRecord
+}
+
+func (m *CommentModel) Get(id int) (*models.Comment, error) {
+	switch id {
+	case 1:
+		return mockComment, nil
+	case 2:
+		return mockReply, nil
+	default:
+		return nil, models.ErrNoRecord
+	}
+}
+
+func (m *CommentModel) Thread(snippetID int) ([]*models.Comment, error) {
+	if snippetID == 1 {
+		return []*models.Comment{mockComment, mockReply, mockLineComment, mockOutdatedComment}, nil
+	}
+
+	return nil, nil
+}
+
+func (m *CommentModel) Update(id int, content string) error {
+	return nil
+}
+
+func (m *CommentModel) Delete(id int) error {
+	switch id {
+	case 1, 2:
+		return nil
+	default:
+		return models.ErrNoRecord
+	}
+}
@@ -0,0 +1,7 @@
+package mocks
+
+type SessionModel struct{}
+
+func (m *SessionModel) PurgeExpired(limit int) (int, error) {
+	return 0, nil
+}
@@ -2,32 +2,304 @@ package mocks
 
 import (
 	"snippetbox/internal/models"
+	"snippetbox/internal/query"
+	"strings"
 	"time"
 )
 
 var mockSnippet = &models.Snippet{
-	ID:      1,
-	Title:   "An old silent pond...",
-	Content: "An old silent pond...",
+	ID:         1,
+	UserID:     1,
+	Author:     "test",
+	Title:      "An old silent pond...",
+	Content:    "An old silent pond...",
+	Tags:       []string{"haiku"},
+	Visibility: models.VisibilityPublic,
+	Comments:   4,
+	Stars:      2,
+	Created:    time.Now(),
+	Expires:    time.Now().Add(24 * time.Hour),
+}
+
+var mockForeignSnippet = &models.Snippet{
+	ID:         3,
+	UserID:     2,
+	Author:     "someone else",
+	Title:      "Over the wintry forest",
+	Content:    "Over the wintry forest, winds howl in rage",
+	Visibility: models.VisibilityUnlisted,
+	Stars:      1,
+	Created:    time.Now(),
+	Expires:    time.Now().Add(24 * time.Hour),
+}
+
+var mockDeletedSnippet = &models.Snippet{
+	ID:      4,
+	UserID:  1,
+	Author:  "test",
+	Title:   "A leaked credential",
+	Content: "password=hunter2",
 	Created: time.Now(),
-	Expires: time.Now(),
+	Expires: time.Now().Add(24 * time.Hour),
+	Deleted: time.Now(),
+}
+
+var mockPrivateSnippet = &models.Snippet{
+	ID:         5,
+	UserID:     1,
+	Author:     "test",
+	Title:      "A diary entry",
+	Content:    "Dear diary...",
+	Visibility: models.VisibilityPrivate,
+	Created:    time.Now(),
+	Expires:    time.Now().Add(24 * time.Hour),
+}
+
+var mockProtectedSnippet = &models.Snippet{
+	ID:         6,
+	UserID:     2,
+	Author:     "someone else",
+	Title:      "Staging credentials",
+	Content:    "user=admin password=hunter2",
+	Visibility: models.VisibilityUnlisted,
+	Protected:  true,
+	Created:    time.Now(),
+	Expires:    time.Now().Add(24 * time.Hour),
+}
+
+var mockBurnSnippet = &models.Snippet{
+	ID:         7,
+	UserID:     2,
+	Author:     "someone else",
+	Title:      "One-time secret",
+	Content:    "The vault code is 1234",
+	Visibility: models.VisibilityUnlisted,
+	ViewsLeft:  new(int),
+	Created:    time.Now(),
+	Expires:    time.Now().Add(24 * time.Hour),
+}
+
+var mockFork = &models.Snippet{
+	ID:           8,
+	UserID:       1,
+	Author:       "test",
+	Title:        "Over the wintry forest, again",
+	Content:      "Over the wintry forest, winds howl in rage, again",
+	Visibility:   models.VisibilityPublic,
+	ForkedFromID: 3,
+	Created:      time.Now(),
+	Expires:      time.Now().Add(24 * time.Hour),
+}
+
+var mockExpiredSnippet = &models.Snippet{
+	ID:         9,
+	UserID:     1,
+	Author:     "test",
+	Title:      "Yesterday's news",
+	Content:    "Nothing to see here",
+	Visibility: models.VisibilityPublic,
+	Created:    time.Now().Add(-48 * time.Hour),
+	Expires:    time.Now().Add(-24 * time.Hour),
+}
+
+// mockSnippetPassword unlocks mockProtectedSnippet
+const mockSnippetPassword = "open sesame"
+
+var mockRevision = &models.Revision{
+	SnippetID: 1,
+	Number:    1,
+	Title:     "An old pond",
+	Content:   "An old pond...",
+	Created:   time.Now(),
 }
 
 type SnippetModel struct{}
 
-func (m *SnippetModel) Insert(title, content string, expires int) (int, error) {
-	return 2, nil
+func (m *SnippetModel) Insert(title, content, language string, tags []string, visibility models.Visibility, password string, maxViews int, expires time.Time, userID, forkedFromID int) (int, error) {
+	return mockSnippet.ID, nil
 }
 
-func (m *SnippetModel) Get(id int) (*models.Snippet, error) {
-	switch id {
-	case 1:
+func (m *SnippetModel) Get(id, viewerID int) (*models.Snippet, error) {
+	switch {
+	case id == 1:
 		return mockSnippet, nil
+	case id == 3:
+		return mockForeignSnippet, nil
+	case id == 5 && viewerID == mockPrivateSnippet.UserID:
+		return mockPrivateSnippet, nil
+	case id == 6:
+		return mockProtectedSnippet, nil
+	case id == 7:
+		return mockBurnSnippet, nil
+	case id == 8:
+		return mockFork, nil
 	default:
 		return nil, models.ErrNoRecord
 	}
 }
 
-func (m *SnippetModel) Latest() ([]*models.Snippet, error) {
-	return []*models.Snippet{mockSnippet}, nil
+func (m *SnippetModel) Peek(id, viewerID int) (*models.Snippet, error) {
+	return m.Get(id, viewerID)
+}
+
+func (m *SnippetModel) Lookup(id, viewerID int) (*models.Snippet, error) {
+	if id == 9 {
+		return mockExpiredSnippet, nil
+	}
+
+	return m.Peek(id, viewerID)
+}
+
+func (m *SnippetModel) Update(id int, title, content, language string, tags []string, visibility models.Visibility, password *string, maxViews int, expires time.Time) error {
+	switch id {
+	case 1, 3, 5:
+		return nil
+	default:
+		return models.ErrNoRecord
+	}
+}
+
+func (m *SnippetModel) Unlock(id int, password string) error {
+	switch {
+	case id == 6 && password == mockSnippetPassword:
+		return nil
+	case id == 1 || id == 3 || id == 6:
+		return models.ErrInvalidCredentials
+	default:
+		return models.ErrNoRecord
+	}
+}
+
+func (m *SnippetModel) Browse(cursor models.Cursor, limit int) (*models.SnippetPage, error) {
+	page := &models.SnippetPage{}
+
+	switch cursor {
+	case models.Cursor{}:
+		page.Snippets = []*models.Snippet{mockSnippet, mockProtectedSnippet, mockBurnSnippet}
+	case models.Cursor{Before: 2}:
+		page.Snippets = []*models.Snippet{mockSnippet}
+		page.After = mockSnippet.ID
+	}
+
+	return page, nil
+}
+
+func (m *SnippetModel) BrowseTag(tag string, cursor models.Cursor, limit int) (*models.SnippetPage, error) {
+	page := &models.SnippetPage{}
+
+	if tag == "haiku" && cursor == (models.Cursor{}) {
+		page.Snippets = []*models.Snippet{mockSnippet}
+	}
+
+	return page, nil
+}
+
+func (m *SnippetModel) TagCloud(limit int) ([]*models.Tag, error) {
+	return []*models.Tag{{Name: "haiku", Count: 1}}, nil
+}
+
+func (m *SnippetModel) ListByOwner(userID int, filter models.OwnerFilter) ([]*models.Snippet, models.SnippetCounts, error) {
+	if userID != 1 {
+		return nil, models.SnippetCounts{}, nil
+	}
+	if filter.Status == models.StatusExpired {
+		return nil, models.SnippetCounts{Active: 1}, nil
+	}
+
+	return []*models.Snippet{mockSnippet}, models.SnippetCounts{Active: 1}, nil
+}
+
+func (m *SnippetModel) Delete(id int) error {
+	switch id {
+	case 1, 3, 9:
+		return nil
+	default:
+		return models.ErrNoRecord
+	}
+}
+
+func (m *SnippetModel) Restore(id, userID int) error {
+	if id == 4 && userID == 1 {
+		return nil
+	}
+
+	return models.ErrNoRecord
+}
+
+func (m *SnippetModel) Trash(userID int) ([]*models.Snippet, error) {
+	if userID == 1 {
+		return []*models.Snippet{mockDeletedSnippet}, nil
+	}
+
+	return nil, nil
+}
+
+func (m *SnippetModel) PurgeTrash(olderThan time.Duration) (int, error) {
+	return 0, nil
+}
+
+func (m *SnippetModel) PurgeExpired(olderThan time.Duration, limit int) (int, error) {
+	return 0, nil
+}
+
+func (m *SnippetModel) Forks(id, viewerID int) ([]*models.Snippet, error) {
+	if id == 3 {
+		return []*models.Snippet{mockFork}, nil
+	}
+
+	return nil, nil
+}
+
+func (m *SnippetModel) Star(id, userID int) error {
+	return nil
+}
+
+func (m *SnippetModel) Unstar(id, userID int) error {
+	return nil
+}
+
+func (m *SnippetModel) HasStarred(id, userID int) (bool, error) {
+	return id == 3 && userID == 1, nil
+}
+
+func (m *SnippetModel) Starred(userID int, sort string) ([]*models.Snippet, error) {
+	if userID == 1 {
+		return []*models.Snippet{mockForeignSnippet}, nil
+	}
+
+	return nil, nil
+}
+
+func (m *SnippetModel) Revisions(id, viewerID int) ([]*models.Revision, error) {
+	if id == 1 {
+		return []*models.Revision{mockRevision}, nil
+	}
+
+	return nil, nil
+}
+
+func (m *SnippetModel) Revision(id, number, viewerID int) (*models.Revision, error) {
+	if id == 1 && number == 1 {
+		return mockRevision, nil
+	}
+
+	return nil, models.ErrNoRecord
+}
+
+func (m *SnippetModel) Search(q *query.Query, page int) (*models.SearchResults, error) {
+	res := &models.SearchResults{Page: page, PageSize: models.SearchPageSize}
+
+	match := strings.Contains(strings.ToLower(q.Words()), "pond")
+	for _, filter := range q.Filters {
+		if filter.Field == query.FieldTag && filter.Value == "haiku" {
+			match = true
+		}
+	}
+
+	if match && page == 1 {
+		res.Snippets = []*models.Snippet{mockSnippet}
+		res.Total = 1
+	}
+
+	return res, nil
 }
@@ -0,0 +1,53 @@
+package mocks
+
+import (
+	"snippetbox/internal/models"
+	"time"
+)
+
+// Tokens accepted by TokenModel.Authenticate
+const (
+	MockReadToken  = "sbx_read"
+	MockWriteToken = "sbx_write"
+)
+
+var mockToken = &models.Token{
+	ID:      1,
+	UserID:  1,
+	Name:    "CI",
+	Scope:   models.TokenScopeWrite,
+	Created: time.Now(),
+}
+
+type TokenModel struct{}
+
+func (m *TokenModel) Insert(userID int, name string, scope models.TokenScope) (string, error) {
+	return MockWriteToken, nil
+}
+
+func (m *TokenModel) Authenticate(plaintext string) (*models.Token, error) {
+	switch plaintext {
+	case MockReadToken:
+		return &models.Token{ID: 2, UserID: 1, Name: "Reader", Scope: models.TokenScopeRead, Created: time.Now()}, nil
+	case MockWriteToken:
+		return mockToken, nil
+	default:
+		return nil, models.ErrInvalidCredentials
+	}
+}
+
+func (m *TokenModel) ListByOwner(userID int) ([]*models.Token, error) {
+	if userID == 1 {
+		return []*models.Token{mockToken}, nil
+	}
+
+	return nil, nil
+}
+
+func (m *TokenModel) Revoke(id, userID int) error {
+	if id == mockToken.ID && userID == mockToken.UserID {
+		return nil
+	}
+
+	return models.ErrNoRecord
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Revision is a past version of a snippet, archived whenever the snippet is
+// updated. Revisions of a snippet are numbered from 1, in order of archiving.
+type Revision struct {
+	SnippetID int
+	Number    int
+	Title     string
+	Content   string
+	Language  string
+	// Created is the time the revision was replaced by a newer version
+	Created time.Time
+}
+
+// Revisions returns all revisions of snippet with given id, oldest first. The
+// snippet itself has to be viewable by user with given viewerID, as with Get.
+func (m *SnippetModel) Revisions(id, viewerID int) ([]*Revision, error) {
+	stmt := `SELECT r.snippet_id, r.number, r.title, r.content, r.language, r.created FROM snippet_revisions r
+				INNER JOIN snippets s ON s.id = r.snippet_id
+				WHERE r.snippet_id = ? AND ` + unexpired + ` AND s.deleted IS NULL AND ` + visibleTo + `
+				ORDER BY r.number`
+	rows, err := m.DB.Query(stmt, id, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*Revision
+
+	for rows.Next() {
+		r := &Revision{}
+		err := rows.Scan(&r.SnippetID, &r.Number, &r.Title, &r.Content, &r.Language, &r.Created)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// Revision returns revision with given number of snippet with given id, provided
+// that user with given viewerID is allowed to see the snippet
+func (m *SnippetModel) Revision(id, number, viewerID int) (*Revision, error) {
+	stmt := `SELECT r.snippet_id, r.number, r.title, r.content, r.language, r.created FROM snippet_revisions r
+				INNER JOIN snippets s ON s.id = r.snippet_id
+				WHERE r.snippet_id = ? AND r.number = ? AND ` + unexpired + ` AND s.deleted IS NULL AND ` + visibleTo
+
+	var r Revision
+	err := m.DB.QueryRow(stmt, id, number, viewerID).Scan(&r.SnippetID, &r.Number, &r.Title, &r.Content, &r.Language, &r.Created)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return &r, nil
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"fmt"
+	"snippetbox/internal/query"
+	"strings"
+	"time"
+)
+
+// SearchPageSize is the number of snippets returned per page of search results
+const SearchPageSize = 10
+
+// SearchResults is a single page of snippets matching a search query, ordered
+// by relevance. Total is the number of matching snippets on all pages.
+type SearchResults struct {
+	Snippets []*Snippet
+	Total    int
+	Page     int
+	PageSize int
+}
+
+// TotalPages returns the number of pages the results are split into
+func (r *SearchResults) TotalPages() int {
+	return (r.Total + r.PageSize - 1) / r.PageSize
+}
+
+// searchMatch matches snippets against a boolean mode expression with the
+// FULLTEXT index on title and content, it's also used as the relevance score
+const searchMatch = `MATCH(s.title, s.content) AGAINST (? IN BOOLEAN MODE)`
+
+// searchClause is a search query compiled into SQL. Where holds conditions
+// with placeholders for args, match the boolean mode expression relevance is
+// scored by, empty when the query has no words to look for.
+type searchClause struct {
+	where []string
+	args  []any
+	match string
+}
+
+// compileSearch turns q into SQL conditions for snippets aliased as s joined
+// with their authors aliased as u. All values are passed as arguments, never
+// spliced into the statement.
+func compileSearch(q *query.Query) (*searchClause, error) {
+	c := &searchClause{
+		// Content of protected and view limited snippets would leak through
+		// the matches.
+		where: []string{unexpired, "s.deleted IS NULL", listed,
+			"s.hashed_password IS NULL", "s.views_left IS NULL"},
+	}
+
+	// Every term is quoted in the boolean mode expression, so that operator
+	// characters in words are taken for word separators.
+	var required, excluded []string
+	for _, term := range q.Terms {
+		text := strings.TrimSpace(strings.ReplaceAll(term.Text, `"`, " "))
+		if text == "" {
+			continue
+		}
+		if term.Negated {
+			excluded = append(excluded, `"`+text+`"`)
+		} else {
+			required = append(required, `+"`+text+`"`)
+		}
+	}
+
+	switch {
+	case len(required) > 0:
+		for _, term := range excluded {
+			required = append(required, "-"+term)
+		}
+		c.match = strings.Join(required, " ")
+		c.add(searchMatch, c.match)
+	case len(excluded) > 0:
+		// boolean mode expressions with no required words match nothing
+		c.add("NOT "+searchMatch, strings.Join(excluded, " "))
+	}
+
+	for _, filter := range q.Filters {
+		var cond string
+		var arg any
+
+		switch filter.Field {
+		case query.FieldAuthor:
+			cond, arg = "u.name = ?", filter.Value
+		case query.FieldLanguage:
+			cond, arg = "s.language = ?", filter.Value
+		case query.FieldBefore:
+			cond, arg = "s.created < ?", filter.Date
+		case query.FieldAfter:
+			// after: excludes the given day itself
+			cond, arg = "s.created >= ?", filter.Date.Add(24*time.Hour)
+		case query.FieldTag:
+			cond, arg = tagged, filter.Value
+		default:
+			return nil, fmt.Errorf("%w: %s filters aren't supported", ErrInvalidQuery, filter.Field)
+		}
+
+		if filter.Negated {
+			cond = "NOT (" + cond + ")"
+		}
+		c.add(cond, arg)
+	}
+
+	return c, nil
+}
+
+func (c *searchClause) add(cond string, args ...any) {
+	c.where = append(c.where, cond)
+	c.args = append(c.args, args...)
+}
+
+// Search returns page (counted from 1) of active public snippets matching q, most
+// relevant first. Queries with filters only are ordered newest first. Password
+// protected and view limited snippets are left out. ErrInvalidQuery is returned
+// for queries that can't be run.
+func (m *SnippetModel) Search(q *query.Query, page int) (*SearchResults, error) {
+	res := &SearchResults{Page: page, PageSize: SearchPageSize}
+
+	c, err := compileSearch(q)
+	if err != nil {
+		return nil, err
+	}
+
+	where := strings.Join(c.where, " AND ")
+
+	countStmt := `SELECT COUNT(*) FROM snippets s
+				INNER JOIN users u ON u.id = s.user_id
+				WHERE ` + where
+
+	err = m.DB.QueryRow(countStmt, c.args...).Scan(&res.Total)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Total == 0 {
+		return res, nil
+	}
+
+	args := c.args
+	order := "s.id DESC"
+	if c.match != "" {
+		order = searchMatch + " DESC, " + order
+		args = append(args, c.match)
+	}
+	args = append(args, SearchPageSize, (page-1)*SearchPageSize)
+
+	stmt := `SELECT ` + snippetFields + ` FROM snippets s
+				INNER JOIN users u ON u.id = s.user_id
+				WHERE ` + where + `
+				ORDER BY ` + order + ` LIMIT ? OFFSET ?`
+
+	rows, err := m.DB.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Snippets, err = scanSnippets(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
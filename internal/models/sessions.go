@@ -0,0 +1,30 @@
+package models
+
+import "database/sql"
+
+type SessionModelInterface interface {
+	PurgeExpired(limit int) (int, error)
+}
+
+// SessionModel manages the sessions table of the MySQL session store
+type SessionModel struct {
+	DB *sql.DB
+}
+
+// PurgeExpired deletes up to limit expired sessions and returns how many of them
+// were removed. Call it again while it removes limit sessions to purge all.
+func (m *SessionModel) PurgeExpired(limit int) (int, error) {
+	stmt := `DELETE FROM sessions WHERE expiry < UTC_TIMESTAMP(6) ORDER BY expiry LIMIT ?`
+
+	res, err := m.DB.Exec(stmt, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
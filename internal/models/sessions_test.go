@@ -0,0 +1,24 @@
+package models
+
+import (
+	"snippetbox/internal/assert"
+	"testing"
+)
+
+func TestSessionModelPurgeExpired(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := SessionModel{DB: db}
+
+	purged, err := m.PurgeExpired(100)
+	assert.NilError(t, err)
+	assert.Equal(t, purged, 1)
+
+	var sessions int
+	assert.NilError(t, db.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&sessions))
+	assert.Equal(t, sessions, 1)
+}
@@ -3,34 +3,249 @@ package models
 import (
 	"database/sql"
 	"errors"
+	"golang.org/x/crypto/bcrypt"
+	"snippetbox/internal/query"
 	"time"
 )
 
 type SnippetModelInterface interface {
-	Insert(title, content string, expires int) (int, error)
-	Get(id int) (*Snippet, error)
-	Latest() ([]*Snippet, error)
+	Insert(title, content, language string, tags []string, visibility Visibility, password string, maxViews int, expires time.Time, userID, forkedFromID int) (int, error)
+	Get(id, viewerID int) (*Snippet, error)
+	Peek(id, viewerID int) (*Snippet, error)
+	Lookup(id, viewerID int) (*Snippet, error)
+	Update(id int, title, content, language string, tags []string, visibility Visibility, password *string, maxViews int, expires time.Time) error
+	Unlock(id int, password string) error
+	Browse(cursor Cursor, limit int) (*SnippetPage, error)
+	BrowseTag(tag string, cursor Cursor, limit int) (*SnippetPage, error)
+	TagCloud(limit int) ([]*Tag, error)
+	ListByOwner(userID int, filter OwnerFilter) ([]*Snippet, SnippetCounts, error)
+	Delete(id int) error
+	Restore(id, userID int) error
+	Trash(userID int) ([]*Snippet, error)
+	PurgeTrash(olderThan time.Duration) (int, error)
+	PurgeExpired(olderThan time.Duration, limit int) (int, error)
+	Forks(id, viewerID int) ([]*Snippet, error)
+	Star(id, userID int) error
+	Unstar(id, userID int) error
+	HasStarred(id, userID int) (bool, error)
+	Starred(userID int, sort string) ([]*Snippet, error)
+	Revisions(id, viewerID int) ([]*Revision, error)
+	Revision(id, number, viewerID int) (*Revision, error)
+	Search(q *query.Query, page int) (*SearchResults, error)
 }
 
+// Visibility controls who can see a snippet
+type Visibility string
+
+const (
+	// VisibilityPublic snippets are listed on all pages and in search results
+	VisibilityPublic Visibility = "public"
+	// VisibilityUnlisted snippets are reachable only by a direct link
+	VisibilityUnlisted Visibility = "unlisted"
+	// VisibilityPrivate snippets are visible only to their owner
+	VisibilityPrivate Visibility = "private"
+)
+
+// Visibilities lists all visibility levels, from the most open one
+var Visibilities = []Visibility{VisibilityPublic, VisibilityUnlisted, VisibilityPrivate}
+
+// listed is a condition on snippets aliased as s matching those that may
+// appear in listings and search results shared by all users
+const listed = `s.visibility = 'public'`
+
+// visibleTo is a condition on snippets aliased as s matching those the user
+// whose id is passed as its argument is allowed to open. Anonymous users are
+// passed as 0, which matches no owner.
+const visibleTo = `(s.visibility <> 'private' OR s.user_id = ?)`
+
+// unexpired is a condition on snippets aliased as s matching those that haven't
+// expired yet, including those that never expire
+const unexpired = `(s.expires IS NULL OR s.expires > UTC_TIMESTAMP())`
+
 type Snippet struct {
 	ID      int
+	UserID  int
+	Author  string
 	Title   string
 	Content string
-	Created time.Time
+	// Language is the name of language the content is highlighted as,
+	// empty for plain text
+	Language string
+	// Tags are sorted by name, they're loaded by Get only
+	Tags       []string
+	Visibility Visibility
+	// Protected is set for snippets that can't be viewed without a password
+	Protected bool
+	// ViewsLeft is the number of times the snippet can still be viewed before
+	// it's deleted, nil when the number of views isn't limited
+	ViewsLeft *int
+	Created   time.Time
+	// Expires is zero for snippets that never expire
 	Expires time.Time
+	// Deleted is zero unless the snippet has been moved to trash
+	Deleted time.Time
+	// Comments is the number of comments on the snippet, not counting deleted
+	// ones
+	Comments int
+	// ForkedFromID is the id of the snippet this one was forked from, 0 when
+	// it isn't a fork or its source was deleted for good
+	ForkedFromID int
+	// Stars is the number of users who starred the snippet
+	Stars int
+}
+
+// nullTime converts t to a nullable column value, NULL when t is zero
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+}
+
+// Expired reports whether the snippet is past its expiration date
+func (s *Snippet) Expired() bool {
+	return !s.Expires.IsZero() && !s.Expires.After(time.Now())
+}
+
+// Burned reports whether the snippet was deleted after its last view
+func (s *Snippet) Burned() bool {
+	return s.ViewsLeft != nil && *s.ViewsLeft == 0
+}
+
+// Status filters accepted by ListByOwner
+const (
+	StatusAll     = "all"
+	StatusActive  = "active"
+	StatusExpired = "expired"
+)
+
+// Sort orders accepted by ListByOwner, Starred accepts SortStarred and
+// SortStars
+const (
+	SortCreated = "created"
+	SortExpires = "expires"
+	// SortStars puts the most starred snippets first
+	SortStars = "stars"
+	// SortStarred puts the most recently starred snippets first
+	SortStarred = "starred"
+)
+
+// OwnerFilter narrows down and orders the snippets listed by ListByOwner.
+// Page is counted from 1.
+type OwnerFilter struct {
+	Status   string
+	Sort     string
+	Page     int
+	PageSize int
+}
+
+// SnippetCounts holds the number of active and expired snippets of a single user
+type SnippetCounts struct {
+	Active  int
+	Expired int
+}
+
+// Total returns the number of all snippets, regardless of their status
+func (c SnippetCounts) Total() int {
+	return c.Active + c.Expired
+}
+
+// Matching returns the number of snippets with given status
+func (c SnippetCounts) Matching(status string) int {
+	switch status {
+	case StatusActive:
+		return c.Active
+	case StatusExpired:
+		return c.Expired
+	default:
+		return c.Total()
+	}
+}
+
+var statusClauses = map[string]string{
+	StatusAll:     "",
+	StatusActive:  " AND " + unexpired,
+	StatusExpired: " AND s.expires <= UTC_TIMESTAMP()",
+}
+
+var sortClauses = map[string]string{
+	SortCreated: " ORDER BY s.created DESC, s.id DESC",
+	SortExpires: " ORDER BY s.expires IS NULL, s.expires ASC, s.id DESC",
+	SortStars:   " ORDER BY star_count DESC, s.id DESC",
+}
+
+// snippetFields are the columns scanned by scanSnippet, every query selecting
+// them has to join users table as u and snippets table as s
+const snippetFields = `s.id, s.user_id, u.name, s.title, s.content, s.language, s.visibility,
+	s.hashed_password IS NOT NULL, s.views_left, s.created, s.expires, s.deleted, s.forked_from_id,
+	(SELECT COUNT(*) FROM comments c WHERE c.snippet_id = s.id AND NOT c.deleted),
+	(SELECT COUNT(*) FROM stars st WHERE st.snippet_id = s.id) AS star_count`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSnippet(row scanner) (*Snippet, error) {
+	var s Snippet
+	var viewsLeft, forkedFromID sql.NullInt64
+	var expires, deleted sql.NullTime
+	err := row.Scan(&s.ID, &s.UserID, &s.Author, &s.Title, &s.Content, &s.Language, &s.Visibility, &s.Protected,
+		&viewsLeft, &s.Created, &expires, &deleted, &forkedFromID, &s.Comments, &s.Stars)
+	if err != nil {
+		return nil, err
+	}
+	if viewsLeft.Valid {
+		views := int(viewsLeft.Int64)
+		s.ViewsLeft = &views
+	}
+	s.Expires = expires.Time
+	s.Deleted = deleted.Time
+	s.ForkedFromID = int(forkedFromID.Int64)
+
+	return &s, nil
+}
+
+func scanSnippets(rows *sql.Rows) ([]*Snippet, error) {
+	var res []*Snippet
+	defer rows.Close()
+
+	for rows.Next() {
+		snippet, err := scanSnippet(rows)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, snippet)
+	}
+
+	if err := rows.Err(); err != nil {
+		return res, err
+	}
+
+	return res, nil
 }
 
 type SnippetModel struct {
 	DB *sql.DB
 }
 
-// Insert into database snippet with given title, content and
-// expiration date set x (specified by expires parameter) days form current date
-func (m *SnippetModel) Insert(title, content string, expires int) (int, error) {
-	stmt := `INSERT INTO snippets (title, content, created, expires)
-			VALUES(?, ?, UTC_TIMESTAMP(), DATE_ADD(UTC_TIMESTAMP(), INTERVAL ? DAY))`
+// Insert into database snippet owned by user with given userID, with given title,
+// content, language, tags, visibility and expiration date, zero for snippets that
+// never expire. The snippet is protected with password, unless it's empty, and
+// deleted after maxViews views, unless it's 0. It's recorded as a fork of snippet
+// with id forkedFromID, unless it's 0.
+func (m *SnippetModel) Insert(title, content, language string, tags []string, visibility Visibility, password string, maxViews int, expires time.Time, userID, forkedFromID int) (int, error) {
+	hashedPassword, err := hashSnippetPassword(password)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt := `INSERT INTO snippets (user_id, title, content, language, visibility, hashed_password, views_left, created, expires, forked_from_id)
+			VALUES(?, ?, ?, ?, ?, ?, NULLIF(?, 0), UTC_TIMESTAMP(), ?, NULLIF(?, 0))`
 
-	res, err := m.DB.Exec(stmt, title, content, expires)
+	res, err := tx.Exec(stmt, userID, title, content, language, visibility, hashedPassword, maxViews, nullTime(expires), forkedFromID)
 	if err != nil {
 		return 0, err
 	}
@@ -40,17 +255,87 @@ func (m *SnippetModel) Insert(title, content string, expires int) (int, error) {
 		return 0, err
 	}
 
-	return int(id), nil
+	err = setTags(tx, int(id), tags)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), tx.Commit()
+}
+
+// Get returns snippet with given id, provided that user with given viewerID
+// is allowed to see it, and counts it as viewed. Views of snippets with limited
+// number of views are counted down, unless made by the owner, and the snippet
+// is deleted with its last view.
+func (m *SnippetModel) Get(id, viewerID int) (*Snippet, error) {
+	res, err := m.Peek(id, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.ViewsLeft == nil || res.UserID == viewerID {
+		return res, nil
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The snippet might have been viewed by someone else since it was read.
+	var viewsLeft int
+
+	lockStmt := `SELECT views_left FROM snippets
+				WHERE id = ? AND views_left IS NOT NULL AND deleted IS NULL FOR UPDATE`
+
+	err = tx.QueryRow(lockStmt, id).Scan(&viewsLeft)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	viewsLeft--
+	if viewsLeft > 0 {
+		_, err = tx.Exec(`UPDATE snippets SET views_left = ? WHERE id = ?`, viewsLeft, id)
+	} else {
+		_, err = tx.Exec(`DELETE FROM snippets WHERE id = ?`, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	res.ViewsLeft = &viewsLeft
+
+	return res, nil
+}
+
+// Peek returns snippet with given id like Get, but without counting a view
+func (m *SnippetModel) Peek(id, viewerID int) (*Snippet, error) {
+	return m.peek(id, viewerID, " AND "+unexpired)
+}
+
+// Lookup returns snippet with given id like Peek, including expired ones, for
+// requests managing the snippet rather than showing it
+func (m *SnippetModel) Lookup(id, viewerID int) (*Snippet, error) {
+	return m.peek(id, viewerID, "")
 }
 
-// Get returns snippet with given id
-func (m *SnippetModel) Get(id int) (*Snippet, error) {
-	stmt := `SELECT id, title, content, created, expires FROM snippets 
-				WHERE id = ? AND EXPIRES > UTC_TIMESTAMP`
-	row := m.DB.QueryRow(stmt, id)
+// peek returns snippet with given id matching extra conditions cond, without
+// counting a view
+func (m *SnippetModel) peek(id, viewerID int, cond string) (*Snippet, error) {
+	stmt := `SELECT ` + snippetFields + ` FROM snippets s
+				INNER JOIN users u ON u.id = s.user_id
+				WHERE s.id = ? AND s.deleted IS NULL AND ` + visibleTo + cond
 
-	var res Snippet
-	err := row.Scan(&res.ID, &res.Title, &res.Content, &res.Created, &res.Expires)
+	res, err := scanSnippet(m.DB.QueryRow(stmt, id, viewerID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNoRecord
@@ -58,32 +343,429 @@ func (m *SnippetModel) Get(id int) (*Snippet, error) {
 		return nil, err
 	}
 
-	return &res, nil
+	res.Tags, err = m.tags(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
 }
 
-// Latest returns max 10 latest snippets ordered by creation order from latest to oldest
-func (m *SnippetModel) Latest() ([]*Snippet, error) {
-	var res []*Snippet
-	stmt := `SELECT id, title, content, created, expires FROM snippets 
-				WHERE EXPIRES > UTC_TIMESTAMP ORDER BY id DESC LIMIT 10`
-	rows, err := m.DB.Query(stmt)
+// Update replaces title, content, language, tags, visibility and expiration date
+// (zero for never) of snippet with given id. The replaced title, content and
+// language are kept as the next numbered revision. The password is left as it is
+// when nil, removed when empty and replaced otherwise. The number of views left
+// is reset to maxViews, 0 lifting the limit.
+func (m *SnippetModel) Update(id int, title, content, language string, tags []string, visibility Visibility, password *string, maxViews int, expires time.Time) error {
+	tx, err := m.DB.Begin()
 	if err != nil {
-		return res, err
+		return err
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
-	for rows.Next() {
-		snippet := &Snippet{}
-		err := rows.Scan(&snippet.ID, &snippet.Title, &snippet.Content, &snippet.Created, &snippet.Expires)
+	var oldTitle, oldContent, oldLanguage string
+
+	lockStmt := `SELECT title, content, language FROM snippets WHERE id = ? AND deleted IS NULL FOR UPDATE`
+
+	err = tx.QueryRow(lockStmt, id).Scan(&oldTitle, &oldContent, &oldLanguage)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		}
+		return err
+	}
+
+	var number int
+
+	numberStmt := `SELECT COALESCE(MAX(number), 0) + 1 FROM snippet_revisions WHERE snippet_id = ?`
+
+	err = tx.QueryRow(numberStmt, id).Scan(&number)
+	if err != nil {
+		return err
+	}
+
+	revisionStmt := `INSERT INTO snippet_revisions (snippet_id, number, title, content, language, created)
+				VALUES (?, ?, ?, ?, ?, UTC_TIMESTAMP())`
+
+	_, err = tx.Exec(revisionStmt, id, number, oldTitle, oldContent, oldLanguage)
+	if err != nil {
+		return err
+	}
+
+	stmt := `UPDATE snippets SET title = ?, content = ?, language = ?, visibility = ?, views_left = NULLIF(?, 0),
+				expires = ?
+				WHERE id = ?`
+
+	_, err = tx.Exec(stmt, title, content, language, visibility, maxViews, nullTime(expires), id)
+	if err != nil {
+		return err
+	}
+
+	if password != nil {
+		hashedPassword, err := hashSnippetPassword(*password)
 		if err != nil {
-			return res, err
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE snippets SET hashed_password = ? WHERE id = ?`, hashedPassword, id)
+		if err != nil {
+			return err
 		}
-		res = append(res, snippet)
 	}
 
-	if err = rows.Err(); err != nil {
-		return res, err
+	err = setTags(tx, id, tags)
+	if err != nil {
+		return err
 	}
 
-	return res, nil
+	return tx.Commit()
+}
+
+// Unlock checks password of snippet with given id. ErrInvalidCredentials is
+// returned when it doesn't match, or when the snippet isn't protected at all.
+func (m *SnippetModel) Unlock(id int, password string) error {
+	var hashedPassword []byte
+
+	stmt := `SELECT hashed_password FROM snippets s WHERE s.id = ? AND ` + unexpired + ` AND s.deleted IS NULL`
+
+	err := m.DB.QueryRow(stmt, id).Scan(&hashedPassword)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		}
+		return err
+	}
+
+	if hashedPassword == nil {
+		return ErrInvalidCredentials
+	}
+
+	err = bcrypt.CompareHashAndPassword(hashedPassword, []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrInvalidCredentials
+		}
+		return err
+	}
+
+	return nil
+}
+
+// hashSnippetPassword hashes password the same way passwords of users are,
+// returning nil for an empty password, which stands for no password
+func hashSnippetPassword(password string) ([]byte, error) {
+	if password == "" {
+		return nil, nil
+	}
+
+	return bcrypt.GenerateFromPassword([]byte(password), 12)
+}
+
+// Cursor selects a page of snippets by keyset pagination on snippet ids.
+// Before selects snippets older than the snippet with that id, After newer
+// ones; the zero Cursor selects the newest snippets.
+type Cursor struct {
+	Before int
+	After  int
+}
+
+// SnippetPage is a single page of snippets, newest first. After and Before are
+// cursors of the adjacent newer and older pages, zero when there's no such page.
+type SnippetPage struct {
+	Snippets []*Snippet
+	After    int
+	Before   int
+}
+
+// Browse returns up to limit active public snippets from the page selected by cursor
+func (m *SnippetModel) Browse(cursor Cursor, limit int) (*SnippetPage, error) {
+	return m.browse("", nil, cursor, limit)
+}
+
+// browse returns a page of active public snippets narrowed down by cond, a
+// condition on snippets aliased as s with placeholders for args
+func (m *SnippetModel) browse(cond string, args []any, cursor Cursor, limit int) (*SnippetPage, error) {
+	where := unexpired + ` AND s.deleted IS NULL AND ` + listed
+	if cond != "" {
+		where += ` AND ` + cond
+	}
+
+	// One more snippet than requested is fetched to tell whether there's
+	// another page in the direction of travel.
+	var stmt string
+	var pageArgs []any
+	switch {
+	case cursor.After > 0:
+		stmt = `AND s.id > ? ORDER BY s.id ASC LIMIT ?`
+		pageArgs = []any{cursor.After, limit + 1}
+	case cursor.Before > 0:
+		stmt = `AND s.id < ? ORDER BY s.id DESC LIMIT ?`
+		pageArgs = []any{cursor.Before, limit + 1}
+	default:
+		stmt = `ORDER BY s.id DESC LIMIT ?`
+		pageArgs = []any{limit + 1}
+	}
+
+	rows, err := m.DB.Query(`SELECT `+snippetFields+` FROM snippets s
+				INNER JOIN users u ON u.id = s.user_id
+				WHERE `+where+` `+stmt, append(args[:len(args):len(args)], pageArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	snippets, err := scanSnippets(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	more := len(snippets) > limit
+	if more {
+		snippets = snippets[:limit]
+	}
+	if cursor.After > 0 {
+		for i, j := 0, len(snippets)-1; i < j; i, j = i+1, j-1 {
+			snippets[i], snippets[j] = snippets[j], snippets[i]
+		}
+	}
+
+	page := &SnippetPage{Snippets: snippets}
+	if len(snippets) == 0 {
+		return page, nil
+	}
+
+	newest, oldest := snippets[0].ID, snippets[len(snippets)-1].ID
+
+	// Whether there's a page on the other side of the cursor is checked
+	// separately, as snippets may have expired or been deleted since the
+	// cursor was handed out.
+	exists := func(idCond string, id int) (bool, error) {
+		var ok bool
+		err := m.DB.QueryRow(`SELECT EXISTS(SELECT true FROM snippets s WHERE `+where+` AND `+idCond+`)`,
+			append(args[:len(args):len(args)], id)...).Scan(&ok)
+		return ok, err
+	}
+
+	newer, older := false, more
+	switch {
+	case cursor.After > 0:
+		newer = more
+		older, err = exists("s.id < ?", oldest)
+	case cursor.Before > 0:
+		newer, err = exists("s.id > ?", newest)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if newer {
+		page.After = newest
+	}
+	if older {
+		page.Before = oldest
+	}
+
+	return page, nil
+}
+
+// ListByOwner returns a single page of snippets created by user with given userID,
+// both active and expired ones unless narrowed down by filter, along with counts
+// of all user's snippets. Snippets moved to trash are left out.
+func (m *SnippetModel) ListByOwner(userID int, filter OwnerFilter) ([]*Snippet, SnippetCounts, error) {
+	var counts SnippetCounts
+
+	statusClause, ok := statusClauses[filter.Status]
+	if !ok {
+		statusClause = statusClauses[StatusAll]
+	}
+	sortClause, ok := sortClauses[filter.Sort]
+	if !ok {
+		sortClause = sortClauses[SortCreated]
+	}
+
+	countStmt := `SELECT COALESCE(SUM(expires IS NULL OR expires > UTC_TIMESTAMP()), 0), COALESCE(SUM(expires <= UTC_TIMESTAMP()), 0)
+				FROM snippets WHERE user_id = ? AND deleted IS NULL`
+
+	err := m.DB.QueryRow(countStmt, userID).Scan(&counts.Active, &counts.Expired)
+	if err != nil {
+		return nil, counts, err
+	}
+
+	stmt := `SELECT ` + snippetFields + ` FROM snippets s
+				INNER JOIN users u ON u.id = s.user_id
+				WHERE s.user_id = ? AND s.deleted IS NULL` + statusClause + sortClause + ` LIMIT ? OFFSET ?`
+
+	rows, err := m.DB.Query(stmt, userID, filter.PageSize, (filter.Page-1)*filter.PageSize)
+	if err != nil {
+		return nil, counts, err
+	}
+
+	snippets, err := scanSnippets(rows)
+	if err != nil {
+		return nil, counts, err
+	}
+
+	return snippets, counts, nil
+}
+
+// Delete moves snippet with given id to trash, where it's hidden from every
+// query except Trash until it's restored or purged
+func (m *SnippetModel) Delete(id int) error {
+	stmt := `UPDATE snippets SET deleted = UTC_TIMESTAMP() WHERE id = ? AND deleted IS NULL`
+
+	_, err := m.DB.Exec(stmt, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Restore brings snippet with given id back from trash, as long as it's owned
+// by user with given userID. ErrNoRecord is returned otherwise.
+func (m *SnippetModel) Restore(id, userID int) error {
+	stmt := `UPDATE snippets SET deleted = NULL WHERE id = ? AND user_id = ? AND deleted IS NOT NULL`
+
+	res, err := m.DB.Exec(stmt, id, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// Forks returns forks of snippet with given id that are listed, along with
+// those owned by user with given viewerID, newest first. Unlisted forks of
+// other users stay reachable only by a direct link.
+func (m *SnippetModel) Forks(id, viewerID int) ([]*Snippet, error) {
+	stmt := `SELECT ` + snippetFields + ` FROM snippets s
+				INNER JOIN users u ON u.id = s.user_id
+				WHERE s.forked_from_id = ? AND ` + unexpired + ` AND s.deleted IS NULL AND (` + listed + ` OR s.user_id = ?)
+				ORDER BY s.id DESC`
+	rows, err := m.DB.Query(stmt, id, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSnippets(rows)
+}
+
+// Star marks snippet with given id as starred by user with given userID.
+// Starring a snippet twice has no effect.
+func (m *SnippetModel) Star(id, userID int) error {
+	stmt := `INSERT IGNORE INTO stars (user_id, snippet_id, created) VALUES(?, ?, UTC_TIMESTAMP())`
+
+	_, err := m.DB.Exec(stmt, userID, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Unstar removes the star user with given userID gave snippet with given id,
+// if there's any
+func (m *SnippetModel) Unstar(id, userID int) error {
+	stmt := `DELETE FROM stars WHERE user_id = ? AND snippet_id = ?`
+
+	_, err := m.DB.Exec(stmt, userID, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// HasStarred reports whether user with given userID starred snippet with given id
+func (m *SnippetModel) HasStarred(id, userID int) (bool, error) {
+	var starred bool
+
+	stmt := `SELECT EXISTS(SELECT true FROM stars WHERE user_id = ? AND snippet_id = ?)`
+
+	err := m.DB.QueryRow(stmt, userID, id).Scan(&starred)
+	if err != nil {
+		return false, err
+	}
+
+	return starred, nil
+}
+
+// Starred returns snippets starred by user with given userID that they're still
+// allowed to see, ordered by sort, which is either SortStarred (the default)
+// or SortStars. Snippets that expired or were moved to trash are left out.
+func (m *SnippetModel) Starred(userID int, sort string) ([]*Snippet, error) {
+	sortClause := " ORDER BY st.created DESC, s.id DESC"
+	if sort == SortStars {
+		sortClause = sortClauses[SortStars]
+	}
+
+	stmt := `SELECT ` + snippetFields + ` FROM stars st
+				INNER JOIN snippets s ON s.id = st.snippet_id
+				INNER JOIN users u ON u.id = s.user_id
+				WHERE st.user_id = ? AND ` + unexpired + ` AND s.deleted IS NULL AND ` + visibleTo + sortClause
+	rows, err := m.DB.Query(stmt, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSnippets(rows)
+}
+
+// Trash returns snippets of user with given userID that were moved to trash,
+// most recently deleted first
+func (m *SnippetModel) Trash(userID int) ([]*Snippet, error) {
+	stmt := `SELECT ` + snippetFields + ` FROM snippets s
+				INNER JOIN users u ON u.id = s.user_id
+				WHERE s.user_id = ? AND s.deleted IS NOT NULL ORDER BY s.deleted DESC`
+	rows, err := m.DB.Query(stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSnippets(rows)
+}
+
+// PurgeTrash permanently deletes snippets that were moved to trash more than
+// olderThan ago and returns how many of them were removed
+func (m *SnippetModel) PurgeTrash(olderThan time.Duration) (int, error) {
+	stmt := `DELETE FROM snippets WHERE deleted < DATE_SUB(UTC_TIMESTAMP(), INTERVAL ? SECOND)`
+
+	res, err := m.DB.Exec(stmt, int(olderThan.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
+
+// PurgeExpired permanently deletes up to limit snippets that expired more than
+// olderThan ago, along with their revisions and tags, and returns how many of
+// them were removed. Call it again while it removes limit snippets to purge all.
+func (m *SnippetModel) PurgeExpired(olderThan time.Duration, limit int) (int, error) {
+	stmt := `DELETE FROM snippets WHERE expires < DATE_SUB(UTC_TIMESTAMP(), INTERVAL ? SECOND)
+				ORDER BY expires LIMIT ?`
+
+	res, err := m.DB.Exec(stmt, int(olderThan.Seconds()), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
 }
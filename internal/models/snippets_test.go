@@ -0,0 +1,773 @@
+package models
+
+import (
+	"errors"
+	"snippetbox/internal/assert"
+	"snippetbox/internal/query"
+	"strings"
+	"testing"
+	"time"
+)
+
+// nextWeek is the expiration date of snippets inserted by tests
+var nextWeek = time.Now().Add(7 * 24 * time.Hour)
+
+func TestSnippetModelGet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	tests := []struct {
+		name       string
+		snippetID  int
+		wantAuthor string
+		wantErr    error
+	}{
+		{
+			name:       "Valid ID",
+			snippetID:  1,
+			wantAuthor: "Alice Jones",
+		},
+		{
+			name:      "Non-existent ID",
+			snippetID: 2,
+			wantErr:   ErrNoRecord,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+
+			m := SnippetModel{DB: db}
+
+			snippet, err := m.Get(tt.snippetID, 0)
+
+			assert.Equal(t, errors.Is(err, tt.wantErr), true)
+			if snippet != nil {
+				assert.Equal(t, snippet.UserID, 1)
+				assert.Equal(t, snippet.Author, tt.wantAuthor)
+			}
+		})
+	}
+}
+
+func TestSnippetModelLookup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := SnippetModel{DB: db}
+
+	_, err := db.Exec("UPDATE snippets SET expires = '2022-01-02 10:00:00' WHERE id = 1")
+	assert.NilError(t, err)
+
+	_, err = m.Peek(1, 1)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+	snippet, err := m.Lookup(1, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, snippet.ID, 1)
+	assert.Equal(t, snippet.Expired(), true)
+
+	assert.NilError(t, m.Delete(1))
+
+	_, err = m.Lookup(1, 1)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+}
+
+func TestSnippetModelListByOwner(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	tests := []struct {
+		name      string
+		userID    int
+		status    string
+		wantCount int
+	}{
+		{
+			name:      "All",
+			userID:    1,
+			status:    StatusAll,
+			wantCount: 1,
+		},
+		{
+			name:      "Expired",
+			userID:    1,
+			status:    StatusExpired,
+			wantCount: 0,
+		},
+		{
+			name:      "Non-existent user",
+			userID:    2,
+			status:    StatusAll,
+			wantCount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+
+			m := SnippetModel{DB: db}
+
+			snippets, counts, err := m.ListByOwner(tt.userID, OwnerFilter{Status: tt.status, Sort: SortCreated, Page: 1, PageSize: 10})
+
+			assert.NilError(t, err)
+			assert.Equal(t, len(snippets), tt.wantCount)
+			assert.Equal(t, counts.Matching(tt.status), tt.wantCount)
+		})
+	}
+}
+
+func TestSnippetModelDeleteRestore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := SnippetModel{DB: db}
+
+	assert.NilError(t, m.Delete(1))
+
+	_, err := m.Get(1, 0)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+	trash, err := m.Trash(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(trash), 1)
+
+	err = m.Restore(1, 2)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+	assert.NilError(t, m.Restore(1, 1))
+
+	_, err = m.Get(1, 0)
+	assert.NilError(t, err)
+}
+
+func TestSnippetModelUpdateRevisions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := SnippetModel{DB: db}
+
+	assert.NilError(t, m.Update(1, "First edit", "First edit content", "go", []string{"haiku"}, VisibilityPublic, nil, 0, nextWeek))
+	assert.NilError(t, m.Update(1, "Second edit", "Second edit content", "", nil, VisibilityPublic, nil, 0, nextWeek))
+
+	revisions, err := m.Revisions(1, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, len(revisions), 2)
+
+	revision, err := m.Revision(1, 2, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, revision.Title, "First edit")
+	assert.Equal(t, revision.Language, "go")
+
+	err = m.Update(2, "Missing", "Missing", "", nil, VisibilityPublic, nil, 0, nextWeek)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+}
+
+func TestSnippetModelSearch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantTotal int
+		wantErr   error
+	}{
+		{
+			name:      "Word",
+			query:     "pond",
+			wantTotal: 1,
+		},
+		{
+			name:      "Phrase",
+			query:     `"silent pond"`,
+			wantTotal: 1,
+		},
+		{
+			name:      "Excluded word",
+			query:     "pond -silent",
+			wantTotal: 0,
+		},
+		{
+			name:      "Excluded only",
+			query:     "-frog",
+			wantTotal: 1,
+		},
+		{
+			name:      "Author",
+			query:     `pond user:"Alice Jones"`,
+			wantTotal: 1,
+		},
+		{
+			name:      "Other author",
+			query:     "author:Bob",
+			wantTotal: 0,
+		},
+		{
+			name:      "Dates",
+			query:     "after:2021-12-31 before:2022-01-02",
+			wantTotal: 1,
+		},
+		{
+			name:      "Excluded date",
+			query:     "-after:2021-12-31",
+			wantTotal: 0,
+		},
+		{
+			name:      "Tag",
+			query:     "tag:haiku",
+			wantTotal: 1,
+		},
+		{
+			name:      "Excluded tag",
+			query:     "pond -tag:haiku",
+			wantTotal: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+
+			m := SnippetModel{DB: db}
+
+			q, err := query.Parse(tt.query)
+			assert.NilError(t, err)
+
+			res, err := m.Search(q, 1)
+
+			assert.Equal(t, errors.Is(err, tt.wantErr), true)
+			if res != nil {
+				assert.Equal(t, res.Total, tt.wantTotal)
+				assert.Equal(t, len(res.Snippets), tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestSnippetModelBrowse(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := SnippetModel{DB: db}
+
+	for _, title := range []string{"Second", "Third"} {
+		_, err := m.Insert(title, "Content", "", []string{"go"}, VisibilityPublic, "", 0, nextWeek, 1, 0)
+		assert.NilError(t, err)
+	}
+
+	tests := []struct {
+		name       string
+		cursor     Cursor
+		wantIDs    []int
+		wantAfter  int
+		wantBefore int
+	}{
+		{
+			name:       "Newest",
+			wantIDs:    []int{3, 2},
+			wantBefore: 2,
+		},
+		{
+			name:      "Older",
+			cursor:    Cursor{Before: 2},
+			wantIDs:   []int{1},
+			wantAfter: 1,
+		},
+		{
+			name:       "Newer",
+			cursor:     Cursor{After: 1},
+			wantIDs:    []int{3, 2},
+			wantBefore: 2,
+		},
+		{
+			name:   "Past the oldest",
+			cursor: Cursor{Before: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := m.Browse(tt.cursor, 2)
+			assert.NilError(t, err)
+
+			assert.Equal(t, len(page.Snippets), len(tt.wantIDs))
+			for i, snippet := range page.Snippets {
+				if i < len(tt.wantIDs) {
+					assert.Equal(t, snippet.ID, tt.wantIDs[i])
+				}
+			}
+			assert.Equal(t, page.After, tt.wantAfter)
+			assert.Equal(t, page.Before, tt.wantBefore)
+		})
+	}
+}
+
+func TestSnippetModelTags(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := SnippetModel{DB: db}
+
+	id, err := m.Insert("Query", "SELECT 1;", "sql", []string{"sql", "haiku"}, VisibilityPublic, "", 0, nextWeek, 1, 0)
+	assert.NilError(t, err)
+
+	snippet, err := m.Get(id, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, strings.Join(snippet.Tags, ","), "haiku,sql")
+
+	page, err := m.BrowseTag("haiku", Cursor{}, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(page.Snippets), 2)
+
+	tags, err := m.TagCloud(10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(tags), 2)
+	if len(tags) == 2 {
+		assert.Equal(t, *tags[0], Tag{Name: "haiku", Count: 2})
+		assert.Equal(t, *tags[1], Tag{Name: "sql", Count: 1})
+	}
+
+	assert.NilError(t, m.Update(id, "Query", "SELECT 1;", "sql", []string{"k8s"}, VisibilityPublic, nil, 0, nextWeek))
+
+	snippet, err = m.Get(id, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, strings.Join(snippet.Tags, ","), "k8s")
+
+	page, err = m.BrowseTag("sql", Cursor{}, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(page.Snippets), 0)
+}
+
+func TestSnippetModelVisibility(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := SnippetModel{DB: db}
+
+	unlisted, err := m.Insert("Unlisted pond", "Unlisted pond", "", []string{"haiku"}, VisibilityUnlisted, "", 0, nextWeek, 1, 0)
+	assert.NilError(t, err)
+	private, err := m.Insert("Private pond", "Private pond", "", []string{"haiku"}, VisibilityPrivate, "", 0, nextWeek, 1, 0)
+	assert.NilError(t, err)
+	assert.NilError(t, m.Update(private, "Private pond", "Still private", "", nil, VisibilityPrivate, nil, 0, nextWeek))
+
+	tests := []struct {
+		name      string
+		snippetID int
+		viewerID  int
+		wantErr   error
+	}{
+		{
+			name:      "Unlisted by anonymous user",
+			snippetID: unlisted,
+		},
+		{
+			name:      "Private by owner",
+			snippetID: private,
+			viewerID:  1,
+		},
+		{
+			name:      "Private by anonymous user",
+			snippetID: private,
+			wantErr:   ErrNoRecord,
+		},
+		{
+			name:      "Private by other user",
+			snippetID: private,
+			viewerID:  2,
+			wantErr:   ErrNoRecord,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.Get(tt.snippetID, tt.viewerID)
+			assert.Equal(t, errors.Is(err, tt.wantErr), true)
+
+			_, err = m.Revisions(tt.snippetID, tt.viewerID)
+			assert.NilError(t, err)
+		})
+	}
+
+	_, err = m.Revision(private, 1, 0)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+	_, err = m.Revision(private, 1, 1)
+	assert.NilError(t, err)
+
+	// only the seeded public snippet may be listed
+	page, err := m.Browse(Cursor{}, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(page.Snippets), 1)
+
+	page, err = m.BrowseTag("haiku", Cursor{}, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(page.Snippets), 1)
+
+	tags, err := m.TagCloud(10)
+	assert.NilError(t, err)
+	if len(tags) == 1 {
+		assert.Equal(t, tags[0].Count, 1)
+	}
+
+	q, err := query.Parse("pond")
+	assert.NilError(t, err)
+
+	res, err := m.Search(q, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, res.Total, 1)
+
+	snippets, _, err := m.ListByOwner(1, OwnerFilter{Status: StatusAll, Sort: SortCreated, Page: 1, PageSize: 10})
+	assert.NilError(t, err)
+	assert.Equal(t, len(snippets), 3)
+}
+
+func TestSnippetModelUnlock(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := SnippetModel{DB: db}
+
+	id, err := m.Insert("Staging credentials", "Pond password", "", nil, VisibilityUnlisted, "s3cret pond", 0, nextWeek, 1, 0)
+	assert.NilError(t, err)
+
+	snippet, err := m.Get(id, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, snippet.Protected, true)
+
+	tests := []struct {
+		name      string
+		snippetID int
+		password  string
+		wantErr   error
+	}{
+		{
+			name:      "Valid password",
+			snippetID: id,
+			password:  "s3cret pond",
+		},
+		{
+			name:      "Wrong password",
+			snippetID: id,
+			password:  "pond",
+			wantErr:   ErrInvalidCredentials,
+		},
+		{
+			name:      "Not protected",
+			snippetID: 1,
+			password:  "",
+			wantErr:   ErrInvalidCredentials,
+		},
+		{
+			name:      "Non-existent ID",
+			snippetID: 2,
+			password:  "s3cret pond",
+			wantErr:   ErrNoRecord,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := m.Unlock(tt.snippetID, tt.password)
+			assert.Equal(t, errors.Is(err, tt.wantErr), true)
+		})
+	}
+
+	// protected snippets don't show up in search results
+	assert.NilError(t, m.Update(id, "Staging credentials", "Pond password", "", nil, VisibilityPublic, nil, 0, nextWeek))
+
+	q, err := query.Parse("pond")
+	assert.NilError(t, err)
+
+	res, err := m.Search(q, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, res.Total, 1)
+
+	password := ""
+	assert.NilError(t, m.Update(id, "Staging credentials", "Pond password", "", nil, VisibilityPublic, &password, 0, nextWeek))
+
+	snippet, err = m.Get(id, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, snippet.Protected, false)
+}
+
+func TestSnippetModelMaxViews(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := SnippetModel{DB: db}
+
+	id, err := m.Insert("Secret", "Burn after reading", "", nil, VisibilityUnlisted, "", 2, nextWeek, 1, 0)
+	assert.NilError(t, err)
+
+	// neither the owner nor peeking uses up views
+	snippet, err := m.Get(id, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, *snippet.ViewsLeft, 2)
+
+	snippet, err = m.Peek(id, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, *snippet.ViewsLeft, 2)
+
+	snippet, err = m.Get(id, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, *snippet.ViewsLeft, 1)
+
+	snippet, err = m.Get(id, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, *snippet.ViewsLeft, 0)
+
+	_, err = m.Get(id, 0)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+	// editing resets the counter and 0 lifts the limit
+	snippet, err = m.Get(1, 0)
+	assert.NilError(t, err)
+	if snippet.ViewsLeft != nil {
+		t.Errorf("got: %d views left; want: unlimited", *snippet.ViewsLeft)
+	}
+
+	assert.NilError(t, m.Update(1, "An old silent pond", "Content", "", nil, VisibilityPublic, nil, 1, nextWeek))
+
+	q, err := query.Parse("pond")
+	assert.NilError(t, err)
+
+	res, err := m.Search(q, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, res.Total, 0)
+
+	assert.NilError(t, m.Update(1, "An old silent pond", "Content", "", nil, VisibilityPublic, nil, 0, nextWeek))
+
+	snippet, err = m.Get(1, 0)
+	assert.NilError(t, err)
+	if snippet.ViewsLeft != nil {
+		t.Errorf("got: %d views left; want: unlimited", *snippet.ViewsLeft)
+	}
+}
+
+func TestSnippetModelExpiry(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := SnippetModel{DB: db}
+
+	forever, err := m.Insert("Forever", "Never expires", "", nil, VisibilityPublic, "", 0, time.Time{}, 1, 0)
+	assert.NilError(t, err)
+
+	expired, err := m.Insert("Gone", "Already expired", "", nil, VisibilityPublic, "", 0, time.Now().Add(-time.Minute), 1, 0)
+	assert.NilError(t, err)
+
+	snippet, err := m.Get(forever, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, snippet.Expires.IsZero(), true)
+	assert.Equal(t, snippet.Expired(), false)
+
+	_, err = m.Get(expired, 0)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+	snippets, counts, err := m.ListByOwner(1, OwnerFilter{Status: StatusActive, Sort: SortExpires, Page: 1, PageSize: 10})
+	assert.NilError(t, err)
+	assert.Equal(t, counts, SnippetCounts{Active: 2, Expired: 1})
+	if len(snippets) == 2 {
+		// snippets that never expire go last
+		assert.Equal(t, snippets[1].ID, forever)
+	}
+
+	// an expiring snippet can be made permanent and the other way round
+	assert.NilError(t, m.Update(forever, "Forever", "Expires after all", "", nil, VisibilityPublic, nil, 0, nextWeek))
+
+	snippet, err = m.Get(forever, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, snippet.Expires.IsZero(), false)
+}
+
+func TestSnippetModelPurgeExpired(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := SnippetModel{DB: db}
+
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	for _, title := range []string{"First", "Second", "Third"} {
+		id, err := m.Insert(title, "Content", "", []string{"haiku"}, VisibilityPublic, "", 0, lastWeek, 1, 0)
+		assert.NilError(t, err)
+		assert.NilError(t, m.Update(id, title, "Edited", "", []string{"haiku"}, VisibilityPublic, nil, 0, lastWeek))
+	}
+	_, err := m.Insert("Recent", "Content", "", nil, VisibilityPublic, "", 0, time.Now().Add(-time.Hour), 1, 0)
+	assert.NilError(t, err)
+	_, err = m.Insert("Forever", "Content", "", nil, VisibilityPublic, "", 0, time.Time{}, 1, 0)
+	assert.NilError(t, err)
+
+	purged, err := m.PurgeExpired(24*time.Hour, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, purged, 2)
+
+	purged, err = m.PurgeExpired(24*time.Hour, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, purged, 1)
+
+	purged, err = m.PurgeExpired(24*time.Hour, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, purged, 0)
+
+	_, counts, err := m.ListByOwner(1, OwnerFilter{Status: StatusAll, Sort: SortCreated, Page: 1, PageSize: 10})
+	assert.NilError(t, err)
+	assert.Equal(t, counts, SnippetCounts{Active: 2, Expired: 1})
+
+	var revisions int
+	assert.NilError(t, db.QueryRow("SELECT COUNT(*) FROM snippet_revisions").Scan(&revisions))
+	assert.Equal(t, revisions, 0)
+}
+
+func TestSnippetModelForks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := SnippetModel{DB: db}
+
+	fork, err := m.Insert("A newer pond", "A newer pond...", "", []string{"haiku"}, VisibilityPublic, "", 0, nextWeek, 1, 1)
+	assert.NilError(t, err)
+
+	private, err := m.Insert("A private pond", "A private pond...", "", nil, VisibilityPrivate, "", 0, nextWeek, 1, 1)
+	assert.NilError(t, err)
+
+	unlisted, err := m.Insert("An unlisted pond", "An unlisted pond...", "", nil, VisibilityUnlisted, "", 0, nextWeek, 1, 1)
+	assert.NilError(t, err)
+
+	snippet, err := m.Get(fork, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, snippet.ForkedFromID, 1)
+
+	forks, err := m.Forks(1, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, len(forks), 1)
+
+	// unlisted forks are left out for other users
+	forks, err = m.Forks(1, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(forks), 1)
+	if len(forks) == 1 {
+		assert.Equal(t, forks[0].ID, fork)
+	}
+
+	forks, err = m.Forks(1, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(forks), 3)
+	if len(forks) == 3 {
+		assert.Equal(t, forks[0].ID, unlisted)
+		assert.Equal(t, forks[1].ID, private)
+	}
+
+	// forks outlive their source
+	_, err = db.Exec("DELETE FROM snippets WHERE id = 1")
+	assert.NilError(t, err)
+
+	snippet, err = m.Get(fork, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, snippet.ForkedFromID, 0)
+}
+
+func TestSnippetModelStars(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := SnippetModel{DB: db}
+
+	_, err := db.Exec(`INSERT INTO users (name, email, hashed_password, created)
+		VALUES ('Bob', 'bob@example.com', '', UTC_TIMESTAMP())`)
+	assert.NilError(t, err)
+
+	other, err := m.Insert("A newer pond", "A newer pond...", "", nil, VisibilityPublic, "", 0, nextWeek, 1, 0)
+	assert.NilError(t, err)
+
+	// starring twice counts once
+	assert.NilError(t, m.Star(1, 1))
+	assert.NilError(t, m.Star(1, 1))
+	assert.NilError(t, m.Star(1, 2))
+	assert.NilError(t, m.Star(other, 1))
+
+	starred, err := m.HasStarred(1, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, starred, true)
+
+	starred, err = m.HasStarred(other, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, starred, false)
+
+	snippet, err := m.Get(1, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, snippet.Stars, 2)
+
+	snippets, _, err := m.ListByOwner(1, OwnerFilter{Sort: SortStars, Page: 1, PageSize: 10})
+	assert.NilError(t, err)
+	assert.Equal(t, len(snippets), 2)
+	if len(snippets) == 2 {
+		assert.Equal(t, snippets[0].ID, 1)
+	}
+
+	snippets, err = m.Starred(1, SortStars)
+	assert.NilError(t, err)
+	assert.Equal(t, len(snippets), 2)
+	if len(snippets) == 2 {
+		assert.Equal(t, snippets[0].ID, 1)
+		assert.Equal(t, snippets[1].Stars, 1)
+	}
+
+	// snippets in trash aren't listed
+	assert.NilError(t, m.Delete(other))
+
+	snippets, err = m.Starred(1, SortStarred)
+	assert.NilError(t, err)
+	assert.Equal(t, len(snippets), 1)
+
+	assert.NilError(t, m.Unstar(1, 1))
+
+	snippets, err = m.Starred(1, SortStarred)
+	assert.NilError(t, err)
+	assert.Equal(t, len(snippets), 0)
+
+	snippet, err = m.Get(1, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, snippet.Stars, 1)
+}
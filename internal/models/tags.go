@@ -0,0 +1,103 @@
+package models
+
+import "database/sql"
+
+// Tag is a label snippets are grouped by. Count is the number of active public
+// snippets tagged with it.
+type Tag struct {
+	Name  string
+	Count int
+}
+
+// tagged is a condition on snippets aliased as s matching those tagged with
+// the tag passed as its argument
+const tagged = `EXISTS (SELECT true FROM snippet_tags st INNER JOIN tags t ON t.id = st.tag_id
+				WHERE st.snippet_id = s.id AND t.name = ?)`
+
+// setTags replaces tags of snippet with given id, creating tags that don't
+// exist yet
+func setTags(tx *sql.Tx, id int, tags []string) error {
+	_, err := tx.Exec(`DELETE FROM snippet_tags WHERE snippet_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		_, err = tx.Exec(`INSERT IGNORE INTO tags (name) VALUES (?)`, tag)
+		if err != nil {
+			return err
+		}
+
+		stmt := `INSERT IGNORE INTO snippet_tags (snippet_id, tag_id) SELECT ?, id FROM tags WHERE name = ?`
+
+		_, err = tx.Exec(stmt, id, tag)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tags returns names of tags of snippet with given id, sorted by name
+func (m *SnippetModel) tags(id int) ([]string, error) {
+	stmt := `SELECT t.name FROM snippet_tags st
+				INNER JOIN tags t ON t.id = st.tag_id
+				WHERE st.snippet_id = ? ORDER BY t.name`
+	rows, err := m.DB.Query(stmt, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		res = append(res, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// BrowseTag returns up to limit active public snippets tagged with tag from the page
+// selected by cursor
+func (m *SnippetModel) BrowseTag(tag string, cursor Cursor, limit int) (*SnippetPage, error) {
+	return m.browse(tagged, []any{tag}, cursor, limit)
+}
+
+// TagCloud returns up to limit tags with the most active public snippets, most
+// used first. Tags used by expired, deleted or non-public snippets only are left out.
+func (m *SnippetModel) TagCloud(limit int) ([]*Tag, error) {
+	stmt := `SELECT t.name, COUNT(*) AS count FROM tags t
+				INNER JOIN snippet_tags st ON st.tag_id = t.id
+				INNER JOIN snippets s ON s.id = st.snippet_id
+				WHERE ` + unexpired + ` AND s.deleted IS NULL AND ` + listed + `
+				GROUP BY t.id, t.name ORDER BY count DESC, t.name LIMIT ?`
+	rows, err := m.DB.Query(stmt, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*Tag
+	for rows.Next() {
+		var tag Tag
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		res = append(res, &tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
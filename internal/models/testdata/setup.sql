@@ -1,11 +1,3 @@
-CREATE TABLE snippets (
-                          id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
-                          title VARCHAR(100) NOT NULL,
-                          content TEXT NOT NULL,
-                          created DATETIME NOT NULL,
-                          expires DATETIME NOT NULL
-);
-CREATE INDEX idx_snippets_created ON snippets(created);
 CREATE TABLE users (
                        id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
                        name VARCHAR(255) NOT NULL,
@@ -14,9 +6,114 @@ CREATE TABLE users (
                        created DATETIME NOT NULL
 );
 ALTER TABLE users ADD CONSTRAINT users_uc_email UNIQUE (email);
+CREATE TABLE snippets (
+                          id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+                          user_id INTEGER NOT NULL,
+                          title VARCHAR(100) NOT NULL,
+                          content TEXT NOT NULL,
+                          language VARCHAR(32) NOT NULL DEFAULT '',
+                          visibility ENUM('public', 'unlisted', 'private') NOT NULL DEFAULT 'public',
+                          hashed_password CHAR(60) NULL,
+                          views_left INTEGER NULL,
+                          created DATETIME NOT NULL,
+                          expires DATETIME NULL,
+                          deleted DATETIME NULL,
+                          forked_from_id INTEGER NULL
+);
+CREATE INDEX idx_snippets_created ON snippets(created);
+CREATE INDEX idx_snippets_deleted ON snippets(deleted);
+CREATE FULLTEXT INDEX idx_snippets_fulltext ON snippets(title, content);
+ALTER TABLE snippets ADD CONSTRAINT snippets_fk_user_id FOREIGN KEY (user_id) REFERENCES users(id);
+ALTER TABLE snippets ADD CONSTRAINT snippets_fk_forked_from_id
+    FOREIGN KEY (forked_from_id) REFERENCES snippets(id) ON DELETE SET NULL;
+CREATE TABLE snippet_revisions (
+                                   snippet_id INTEGER NOT NULL,
+                                   number INTEGER NOT NULL,
+                                   title VARCHAR(100) NOT NULL,
+                                   content TEXT NOT NULL,
+                                   language VARCHAR(32) NOT NULL DEFAULT '',
+                                   created DATETIME NOT NULL,
+                                   PRIMARY KEY (snippet_id, number)
+);
+ALTER TABLE snippet_revisions ADD CONSTRAINT snippet_revisions_fk_snippet_id
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE;
+CREATE TABLE tags (
+                      id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+                      name VARCHAR(32) NOT NULL
+);
+ALTER TABLE tags ADD CONSTRAINT tags_uc_name UNIQUE (name);
+CREATE TABLE snippet_tags (
+                              snippet_id INTEGER NOT NULL,
+                              tag_id INTEGER NOT NULL,
+                              PRIMARY KEY (snippet_id, tag_id)
+);
+CREATE INDEX idx_snippet_tags_tag_id ON snippet_tags(tag_id);
+ALTER TABLE snippet_tags ADD CONSTRAINT snippet_tags_fk_snippet_id
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE;
+ALTER TABLE snippet_tags ADD CONSTRAINT snippet_tags_fk_tag_id
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE;
+CREATE TABLE comments (
+                          id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+                          snippet_id INTEGER NOT NULL,
+                          user_id INTEGER NOT NULL,
+                          parent_id INTEGER NULL,
+                          version INTEGER NULL,
+                          line_start INTEGER NULL,
+                          line_end INTEGER NULL,
+                          content TEXT NOT NULL,
+                          created DATETIME NOT NULL,
+                          edited DATETIME NULL,
+                          deleted BOOLEAN NOT NULL DEFAULT FALSE
+);
+ALTER TABLE comments ADD CONSTRAINT comments_fk_snippet_id
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE;
+ALTER TABLE comments ADD CONSTRAINT comments_fk_user_id FOREIGN KEY (user_id) REFERENCES users(id);
+ALTER TABLE comments ADD CONSTRAINT comments_fk_parent_id
+    FOREIGN KEY (parent_id) REFERENCES comments(id) ON DELETE CASCADE;
+CREATE TABLE stars (
+                       user_id INTEGER NOT NULL,
+                       snippet_id INTEGER NOT NULL,
+                       created DATETIME NOT NULL,
+                       PRIMARY KEY (user_id, snippet_id)
+);
+CREATE INDEX idx_stars_snippet_id ON stars(snippet_id);
+ALTER TABLE stars ADD CONSTRAINT stars_fk_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE stars ADD CONSTRAINT stars_fk_snippet_id
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE;
+CREATE TABLE api_tokens (
+                            id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+                            user_id INTEGER NOT NULL,
+                            name VARCHAR(100) NOT NULL,
+                            scope ENUM('read', 'write') NOT NULL,
+                            hash CHAR(64) NOT NULL,
+                            created DATETIME NOT NULL,
+                            last_used DATETIME NULL
+);
+ALTER TABLE api_tokens ADD CONSTRAINT api_tokens_uc_hash UNIQUE (hash);
+ALTER TABLE api_tokens ADD CONSTRAINT api_tokens_fk_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+CREATE TABLE sessions (
+                          token CHAR(43) PRIMARY KEY,
+                          data BLOB NOT NULL,
+                          expiry TIMESTAMP(6) NOT NULL
+);
+CREATE INDEX sessions_expiry_idx ON sessions (expiry);
 INSERT INTO users (name, email, hashed_password, created) VALUES (
                                                                      'Alice Jones',
                                                                      'alice@example.com',
                                                                      '$2a$12$NuTjWXm3KKntReFwyBVHyuf/to.HEwTy.eS206TNfkGfr6HzGJSWG',
                                                                      '2022-01-01 10:00:00'
                                                                  );
+INSERT INTO snippets (user_id, title, content, created, expires) VALUES (
+                                                                            1,
+                                                                            'An old silent pond',
+                                                                            'An old silent pond...',
+                                                                            '2022-01-01 10:00:00',
+                                                                            '2099-01-01 10:00:00'
+                                                                        );
+INSERT INTO tags (name) VALUES ('haiku');
+INSERT INTO snippet_tags (snippet_id, tag_id) VALUES (1, 1);
+INSERT INTO sessions (token, data, expiry) VALUES
+    ('stale', '', '2022-01-01 10:00:00'),
+    ('fresh', '', '2099-01-01 10:00:00');
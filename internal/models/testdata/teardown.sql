@@ -1,2 +1,9 @@
-DROP TABLE users;
+DROP TABLE stars;
+DROP TABLE comments;
+DROP TABLE api_tokens;
+DROP TABLE sessions;
+DROP TABLE snippet_tags;
+DROP TABLE tags;
+DROP TABLE snippet_revisions;
 DROP TABLE snippets;
+DROP TABLE users;
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+type TokenModelInterface interface {
+	Insert(userID int, name string, scope TokenScope) (string, error)
+	Authenticate(plaintext string) (*Token, error)
+	ListByOwner(userID int) ([]*Token, error)
+	Revoke(id, userID int) error
+}
+
+// TokenScope limits what an API token can be used for
+type TokenScope string
+
+const (
+	// TokenScopeRead tokens can only read snippets
+	TokenScopeRead TokenScope = "read"
+	// TokenScopeWrite tokens can also create, change and delete snippets
+	TokenScopeWrite TokenScope = "write"
+)
+
+// TokenScopes lists all token scopes, from the narrowest one
+var TokenScopes = []TokenScope{TokenScopeRead, TokenScopeWrite}
+
+// Allows reports whether tokens with the scope can be used for requests that
+// need scope required
+func (s TokenScope) Allows(required TokenScope) bool {
+	return s == TokenScopeWrite || s == required
+}
+
+// tokenPrefix starts every token, which makes leaked ones easy to spot
+const tokenPrefix = "sbx_"
+
+// Token is a personal API token. Only a hash of the token itself is stored,
+// it's shown to its owner once when created.
+type Token struct {
+	ID     int
+	UserID int
+	Name   string
+	Scope  TokenScope
+	// LastUsed is zero for tokens that were never used
+	LastUsed time.Time
+	Created  time.Time
+}
+
+type TokenModel struct {
+	DB *sql.DB
+}
+
+// hashToken returns the hex encoded SHA-256 hash of plaintext. Tokens are long
+// random strings, so unlike passwords they don't need a slow hash.
+func hashToken(plaintext string) string {
+	hash := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(hash[:])
+}
+
+// Insert creates a token with given name and scope for user with given userID
+// and returns it in plaintext
+func (m *TokenModel) Insert(userID int, name string, scope TokenScope) (string, error) {
+	random := make([]byte, 20)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext := tokenPrefix + strings.ToLower(base32.StdEncoding.EncodeToString(random))
+
+	stmt := `INSERT INTO api_tokens (user_id, name, scope, hash, created)
+			VALUES(?, ?, ?, ?, UTC_TIMESTAMP())`
+
+	_, err = m.DB.Exec(stmt, userID, name, scope, hashToken(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return plaintext, nil
+}
+
+// Authenticate returns the token matching plaintext and records that it was
+// used. ErrInvalidCredentials is returned for unknown and revoked tokens.
+func (m *TokenModel) Authenticate(plaintext string) (*Token, error) {
+	if !strings.HasPrefix(plaintext, tokenPrefix) {
+		return nil, ErrInvalidCredentials
+	}
+
+	stmt := `SELECT id, user_id, name, scope, created, last_used FROM api_tokens WHERE hash = ?`
+
+	t, err := scanToken(m.DB.QueryRow(stmt, hashToken(plaintext)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	_, err = m.DB.Exec(`UPDATE api_tokens SET last_used = UTC_TIMESTAMP() WHERE id = ?`, t.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// ListByOwner returns tokens of user with given userID, newest first
+func (m *TokenModel) ListByOwner(userID int) ([]*Token, error) {
+	stmt := `SELECT id, user_id, name, scope, created, last_used FROM api_tokens
+			WHERE user_id = ? ORDER BY id DESC`
+
+	rows, err := m.DB.Query(stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*Token
+	for rows.Next() {
+		t, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Revoke deletes token with given id, provided that it belongs to user with
+// given userID
+func (m *TokenModel) Revoke(id, userID int) error {
+	res, err := m.DB.Exec(`DELETE FROM api_tokens WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func scanToken(row scanner) (*Token, error) {
+	t := &Token{}
+
+	var lastUsed sql.NullTime
+	err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.Scope, &t.Created, &lastUsed)
+	if err != nil {
+		return nil, err
+	}
+	t.LastUsed = lastUsed.Time
+
+	return t, nil
+}
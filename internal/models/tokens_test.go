@@ -0,0 +1,82 @@
+package models
+
+import (
+	"errors"
+	"snippetbox/internal/assert"
+	"strings"
+	"testing"
+)
+
+func TestTokenModel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("models: skipping integration test")
+	}
+
+	db := newTestDB(t)
+
+	m := TokenModel{DB: db}
+
+	plaintext, err := m.Insert(1, "CI", TokenScopeWrite)
+	assert.NilError(t, err)
+	assert.Equal(t, strings.HasPrefix(plaintext, tokenPrefix), true)
+
+	tokens, err := m.ListByOwner(1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(tokens), 1)
+	if len(tokens) == 1 {
+		assert.Equal(t, tokens[0].Name, "CI")
+		assert.Equal(t, tokens[0].LastUsed.IsZero(), true)
+	}
+
+	// only the hash is stored
+	var stored int
+	assert.NilError(t, db.QueryRow("SELECT COUNT(*) FROM api_tokens WHERE hash = ?", plaintext).Scan(&stored))
+	assert.Equal(t, stored, 0)
+
+	token, err := m.Authenticate(plaintext)
+	assert.NilError(t, err)
+	assert.Equal(t, token.UserID, 1)
+	assert.Equal(t, token.Scope, TokenScopeWrite)
+
+	tokens, err = m.ListByOwner(1)
+	assert.NilError(t, err)
+	if len(tokens) == 1 {
+		assert.Equal(t, tokens[0].LastUsed.IsZero(), false)
+	}
+
+	tests := []struct {
+		name      string
+		plaintext string
+	}{
+		{
+			name:      "Unknown token",
+			plaintext: tokenPrefix + "unknown",
+		},
+		{
+			name:      "Missing prefix",
+			plaintext: strings.TrimPrefix(plaintext, tokenPrefix),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.Authenticate(tt.plaintext)
+			assert.Equal(t, errors.Is(err, ErrInvalidCredentials), true)
+		})
+	}
+
+	err = m.Revoke(token.ID, 2)
+	assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+	assert.NilError(t, m.Revoke(token.ID, 1))
+
+	_, err = m.Authenticate(plaintext)
+	assert.Equal(t, errors.Is(err, ErrInvalidCredentials), true)
+}
+
+func TestTokenScopeAllows(t *testing.T) {
+	assert.Equal(t, TokenScopeRead.Allows(TokenScopeRead), true)
+	assert.Equal(t, TokenScopeRead.Allows(TokenScopeWrite), false)
+	assert.Equal(t, TokenScopeWrite.Allows(TokenScopeRead), true)
+	assert.Equal(t, TokenScopeWrite.Allows(TokenScopeWrite), true)
+}
@@ -0,0 +1,254 @@
+// Package query parses the search query language of snippet search. A query
+// is a whitespace separated list of:
+//
+//	word            snippets containing the word
+//	"some phrase"   snippets containing the exact phrase
+//	field:value     snippets with matching metadata, see Field
+//	field:"a b"     as above, for values containing whitespace
+//
+// Any of them can be negated with a leading minus, e.g. -word or -lang:go.
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Field is the name of a metadata field snippets can be filtered by
+type Field string
+
+const (
+	FieldAuthor   Field = "user"
+	FieldLanguage Field = "lang"
+	FieldTag      Field = "tag"
+	FieldBefore   Field = "before"
+	FieldAfter    Field = "after"
+)
+
+// DateLayout is the format of before: and after: values
+const DateLayout = "2006-01-02"
+
+// fields maps field names accepted in queries, including aliases, to fields
+var fields = map[string]Field{
+	"user":     FieldAuthor,
+	"author":   FieldAuthor,
+	"lang":     FieldLanguage,
+	"language": FieldLanguage,
+	"tag":      FieldTag,
+	"before":   FieldBefore,
+	"after":    FieldAfter,
+}
+
+// Term is a word or a phrase to be found in snippet title or content
+type Term struct {
+	Text    string
+	Phrase  bool
+	Negated bool
+}
+
+// Filter narrows down snippets by metadata. Date is set for FieldBefore and
+// FieldAfter filters only.
+type Filter struct {
+	Field   Field
+	Value   string
+	Date    time.Time
+	Negated bool
+}
+
+type Query struct {
+	Terms   []Term
+	Filters []Filter
+}
+
+// Error describes a syntax error at position Pos (in bytes) of the query
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("query: %s at position %d", e.Msg, e.Pos)
+}
+
+// Empty reports whether the query has neither terms nor filters
+func (q *Query) Empty() bool {
+	return len(q.Terms) == 0 && len(q.Filters) == 0
+}
+
+// Words returns the text of all terms that aren't negated, separated with
+// spaces, e.g. for highlighting matches of the query
+func (q *Query) Words() string {
+	var words []string
+	for _, term := range q.Terms {
+		if !term.Negated {
+			words = append(words, term.Text)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// String returns the query in its canonical form, which parses back into an
+// equal query
+func (q *Query) String() string {
+	var parts []string
+
+	for _, term := range q.Terms {
+		part := term.Text
+		if term.Phrase {
+			part = `"` + part + `"`
+		}
+		if term.Negated {
+			part = "-" + part
+		}
+		parts = append(parts, part)
+	}
+
+	for _, filter := range q.Filters {
+		value := filter.Value
+		if strings.IndexFunc(value, unicode.IsSpace) >= 0 || value == "" {
+			value = `"` + value + `"`
+		}
+		part := string(filter.Field) + ":" + value
+		if filter.Negated {
+			part = "-" + part
+		}
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Parse parses input into a Query. Words looking like field:value with an
+// unknown field are treated as plain words, so that searching for e.g. URLs
+// works as expected.
+func Parse(input string) (*Query, error) {
+	q := &Query{}
+	p := &parser{input: input}
+
+	for {
+		p.skipSpace()
+		if p.done() {
+			return q, nil
+		}
+
+		start := p.pos
+
+		negated := false
+		if p.peek() == '-' && p.pos+1 < len(p.input) && !isSpace(p.input[p.pos+1]) {
+			negated = true
+			p.pos++
+		}
+
+		if p.peek() == '"' {
+			text, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(text) != "" {
+				q.Terms = append(q.Terms, Term{Text: text, Phrase: true, Negated: negated})
+			}
+			continue
+		}
+
+		word := p.word()
+
+		name, value, ok := strings.Cut(word, ":")
+		field, known := fields[strings.ToLower(name)]
+		if !ok || !known {
+			q.Terms = append(q.Terms, Term{Text: word, Negated: negated})
+			continue
+		}
+
+		if value == "" && p.peek() == '"' {
+			var err error
+			value, err = p.quoted()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		filter, err := newFilter(field, value, negated)
+		if err != nil {
+			return nil, &Error{Pos: start, Msg: err.Error()}
+		}
+		q.Filters = append(q.Filters, filter)
+	}
+}
+
+func newFilter(field Field, value string, negated bool) (Filter, error) {
+	filter := Filter{Field: field, Value: strings.TrimSpace(value), Negated: negated}
+
+	if filter.Value == "" {
+		return filter, fmt.Errorf("missing value of %s filter", field)
+	}
+
+	switch field {
+	case FieldLanguage, FieldTag:
+		filter.Value = strings.ToLower(filter.Value)
+	case FieldBefore, FieldAfter:
+		date, err := time.Parse(DateLayout, filter.Value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s date %q, expected YYYY-MM-DD", field, filter.Value)
+		}
+		filter.Date = date
+	}
+
+	return filter, nil
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+// peek returns the current byte, or 0 at the end of input
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for !p.done() && isSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// word consumes input up to the next whitespace or quote
+func (p *parser) word() string {
+	start := p.pos
+	for !p.done() && !isSpace(p.input[p.pos]) && p.input[p.pos] != '"' {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+// quoted consumes a quoted string, the current byte being the opening quote,
+// and returns its content
+func (p *parser) quoted() (string, error) {
+	start := p.pos
+	p.pos++
+
+	end := strings.IndexByte(p.input[p.pos:], '"')
+	if end < 0 {
+		return "", &Error{Pos: start, Msg: "unterminated quote"}
+	}
+
+	text := p.input[p.pos : p.pos+end]
+	p.pos += end + 1
+
+	return text, nil
+}
+
+// isSpace reports whether b is ASCII whitespace; multibyte whitespace is part
+// of words, which keeps the parser byte oriented
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f'
+}
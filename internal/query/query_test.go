@@ -0,0 +1,161 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"snippetbox/internal/assert"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  *Query
+	}{
+		{
+			name:  "Empty",
+			input: "  ",
+			want:  &Query{},
+		},
+		{
+			name:  "Words",
+			input: "old  pond",
+			want:  &Query{Terms: []Term{{Text: "old"}, {Text: "pond"}}},
+		},
+		{
+			name:  "Phrase",
+			input: `"old silent pond" -frog -"green frog"`,
+			want: &Query{Terms: []Term{
+				{Text: "old silent pond", Phrase: true},
+				{Text: "frog", Negated: true},
+				{Text: "green frog", Phrase: true, Negated: true},
+			}},
+		},
+		{
+			name:  "Lone minus",
+			input: "a - b",
+			want:  &Query{Terms: []Term{{Text: "a"}, {Text: "-"}, {Text: "b"}}},
+		},
+		{
+			name:  "Filters",
+			input: `pond LANG:Go -tag:haiku author:"Alice Jones"`,
+			want: &Query{
+				Terms: []Term{{Text: "pond"}},
+				Filters: []Filter{
+					{Field: FieldLanguage, Value: "go"},
+					{Field: FieldTag, Value: "haiku", Negated: true},
+					{Field: FieldAuthor, Value: "Alice Jones"},
+				},
+			},
+		},
+		{
+			name:  "Dates",
+			input: "after:2022-01-01 before:2023-06-30",
+			want: &Query{Filters: []Filter{
+				{Field: FieldAfter, Value: "2022-01-01", Date: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+				{Field: FieldBefore, Value: "2023-06-30", Date: time.Date(2023, 6, 30, 0, 0, 0, 0, time.UTC)},
+			}},
+		},
+		{
+			name:  "Unknown field",
+			input: "https://example.com",
+			want:  &Query{Terms: []Term{{Text: "https://example.com"}}},
+		},
+		{
+			name:  "Empty phrase",
+			input: `"" " "`,
+			want:  &Query{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse(tt.input)
+			assert.NilError(t, err)
+
+			if !reflect.DeepEqual(q, tt.want) {
+				t.Errorf("got: %+v; want: %+v", q, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantPos int
+	}{
+		{
+			name:    "Unterminated phrase",
+			input:   `pond "old`,
+			wantPos: 5,
+		},
+		{
+			name:    "Unterminated value",
+			input:   `user:"Alice`,
+			wantPos: 5,
+		},
+		{
+			name:    "Missing value",
+			input:   "pond lang:",
+			wantPos: 5,
+		},
+		{
+			name:    "Invalid date",
+			input:   "-before:yesterday",
+			wantPos: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+
+			var qerr *Error
+			assert.Equal(t, errors.As(err, &qerr), true)
+			if qerr != nil {
+				assert.Equal(t, qerr.Pos, tt.wantPos)
+			}
+		})
+	}
+}
+
+func TestWords(t *testing.T) {
+	q, err := Parse(`old -frog "silent pond" lang:go`)
+	assert.NilError(t, err)
+
+	assert.Equal(t, q.Words(), "old silent pond")
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"old pond",
+		`"old silent pond" -frog`,
+		`user:"Alice Jones" -lang:go tag:haiku`,
+		"after:2022-01-01 -before:2023-06-30",
+		`-"unterminated`,
+		`a"b"c:d -:`,
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		q, err := Parse(input)
+		if err != nil {
+			return
+		}
+
+		// the canonical form must describe the same query
+		again, err := Parse(q.String())
+		if err != nil {
+			t.Fatalf("parsing %q (from %q): %v", q.String(), input, err)
+		}
+		if !reflect.DeepEqual(q, again) {
+			t.Fatalf("round trip of %q: got %+v; want %+v", input, again, q)
+		}
+	})
+}
@@ -66,6 +66,11 @@ func IsEmailAddress(value string) bool {
 	return rxEmail.MatchString(value)
 }
 
+// Matches returns true if a value matches a provided compiled regular expression.
+func Matches(value string, rx *regexp.Regexp) bool {
+	return rx.MatchString(value)
+}
+
 // PermittedValue returns true if a value is in a list of permitted integers.
 func PermittedValue[T comparable](value T, permittedValues ...T) bool {
 	for i := range permittedValues {
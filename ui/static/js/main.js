@@ -5,4 +5,64 @@ for (var i = 0; i < navLinks.length; i++) {
 		link.classList.add("live");
 		break;
 	}
-}
\ No newline at end of file
+}
+
+// Snippet lines are linked to as #L10, or #L10-L14 for a range, which is
+// highlighted here as CSS can only target a single line
+var linePattern = /^#L(\d+)(?:-L(\d+))?$/;
+
+function highlightLines() {
+	var highlighted = document.querySelectorAll("pre.lines .line.hl");
+	for (var i = 0; i < highlighted.length; i++) {
+		highlighted[i].classList.remove("hl");
+	}
+
+	var match = linePattern.exec(window.location.hash);
+	if (!match) {
+		return;
+	}
+	var start = parseInt(match[1], 10);
+	var end = match[2] ? parseInt(match[2], 10) : start;
+
+	// Walk the lines on the page rather than the range, which comes from the
+	// URL and can be arbitrarily long
+	var lines = document.querySelectorAll("pre.lines .line");
+	for (var j = 0; j < lines.length; j++) {
+		var n = parseInt(lines[j].id.slice(1), 10);
+		if (n >= start && n <= end) {
+			lines[j].classList.add("hl");
+		}
+	}
+
+	var first = document.getElementById("L" + start);
+	if (first && match[2]) {
+		first.scrollIntoView();
+	}
+
+	// Prefill the comment form with the picked lines
+	var lineStart = document.querySelector("input[name='line_start']");
+	var lineEnd = document.querySelector("input[name='line_end']");
+	if (lineStart && lineEnd) {
+		lineStart.value = start;
+		lineEnd.value = end;
+	}
+}
+
+// Shift-clicking a line number extends the highlighted line to a range
+var lineLinks = document.querySelectorAll("pre.lines a.lnlinks");
+for (var i = 0; i < lineLinks.length; i++) {
+	lineLinks[i].addEventListener("click", function(event) {
+		var match = linePattern.exec(window.location.hash);
+		if (!event.shiftKey || !match) {
+			return;
+		}
+		event.preventDefault();
+
+		var from = parseInt(match[1], 10);
+		var to = parseInt(this.textContent, 10);
+		window.location.hash = "#L" + Math.min(from, to) + "-L" + Math.max(from, to);
+	});
+}
+
+window.addEventListener("hashchange", highlightLines);
+highlightLines();